@@ -18,9 +18,19 @@
 package zero
 
 type IRouter interface {
-	GET(pattern string, handlers ...HandlerFunc)
-	POST(pattern string, handlers ...HandlerFunc)
-	PUT(pattern string, handlers ...HandlerFunc)
-	DELETE(pattern string, handlers ...HandlerFunc)
-	HEAD(pattern string, handlers ...HandlerFunc)
+	GET(pattern string, handlers ...HandlerFunc) *Route
+	POST(pattern string, handlers ...HandlerFunc) *Route
+	PUT(pattern string, handlers ...HandlerFunc) *Route
+	DELETE(pattern string, handlers ...HandlerFunc) *Route
+	HEAD(pattern string, handlers ...HandlerFunc) *Route
+	PATCH(pattern string, handlers ...HandlerFunc) *Route
+	OPTIONS(pattern string, handlers ...HandlerFunc) *Route
+	Any(pattern string, handlers ...HandlerFunc) []*Route
+	Match(methods []string, pattern string, handlers ...HandlerFunc) []*Route
+	Handle(method string, pattern string, handlers ...HandlerFunc) *Route
 }
+
+var (
+	_ IRouter = (*Engine)(nil)
+	_ IRouter = (*RouterGroup)(nil)
+)
@@ -0,0 +1,441 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Binding 请求绑定器, 将请求中的数据解析到结构体中
+type Binding interface {
+	// Name 绑定器名称
+	Name() string
+	// Bind 将请求数据解析到 obj 中, obj 必须为指针
+	Bind(req *http.Request, obj interface{}) error
+}
+
+// URIBinding 路径参数绑定器
+type URIBinding interface {
+	// Name 绑定器名称
+	Name() string
+	// BindURI 将路径参数解析到 obj 中, obj 必须为指针
+	BindURI(params Params, obj interface{}) error
+}
+
+// 内置绑定器
+var (
+	BindingJSON      Binding    = jsonBinding{}
+	BindingXML       Binding    = xmlBinding{}
+	BindingForm      Binding    = formBinding{}
+	BindingFormPost  Binding    = formPostBinding{}
+	BindingMultipart Binding    = multipartBinding{}
+	BindingQuery     Binding    = queryBinding{}
+	BindingHeader    Binding    = headerBinding{}
+	BindingURI       URIBinding = uriBinding{}
+)
+
+var (
+	bindingsMu sync.RWMutex
+	// bindings 按 Content-Type 选择的绑定器
+	bindings = map[string]Binding{
+		"application/json":                  BindingJSON,
+		"application/xml":                   BindingXML,
+		"text/xml":                          BindingXML,
+		"application/x-www-form-urlencoded": BindingForm,
+		"multipart/form-data":               BindingMultipart,
+	}
+)
+
+// RegisterBinding 注册 Content-Type 对应的绑定器, 已存在时覆盖
+func RegisterBinding(contentType string, b Binding) {
+	bindingsMu.Lock()
+	defer bindingsMu.Unlock()
+	bindings[strings.ToLower(contentType)] = b
+}
+
+// defaultBinding 根据请求方式与 Content-Type 选择绑定器
+// GET 请求以及未注册的 Content-Type 使用 BindingForm
+func defaultBinding(method, contentType string) Binding {
+	if method == GET || method == HEAD {
+		return BindingForm
+	}
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+
+	bindingsMu.RLock()
+	defer bindingsMu.RUnlock()
+	if b, ok := bindings[contentType]; ok {
+		return b
+	}
+	return BindingForm
+}
+
+type jsonBinding struct{}
+
+func (jsonBinding) Name() string { return "json" }
+
+func (jsonBinding) Bind(req *http.Request, obj interface{}) error {
+	if req == nil || req.Body == nil {
+		return errors.New("zero: invalid request, body is empty")
+	}
+	return json.NewDecoder(req.Body).Decode(obj)
+}
+
+type xmlBinding struct{}
+
+func (xmlBinding) Name() string { return "xml" }
+
+func (xmlBinding) Bind(req *http.Request, obj interface{}) error {
+	if req == nil || req.Body == nil {
+		return errors.New("zero: invalid request, body is empty")
+	}
+	return xml.NewDecoder(req.Body).Decode(obj)
+}
+
+// memoryBinding 解析 multipart/form-data 的绑定器, 由 Context 传入 Engine.MaxMultipartMemory
+type memoryBinding interface {
+	Binding
+	// BindMemory 与 Bind 相同, maxMemory 为存放在内存中的最大字节数
+	BindMemory(req *http.Request, obj interface{}, maxMemory int64) error
+}
+
+// formBinding 绑定查询参数与表单参数, 表单参数优先
+type formBinding struct{}
+
+func (formBinding) Name() string { return "form" }
+
+func (b formBinding) Bind(req *http.Request, obj interface{}) error {
+	return b.BindMemory(req, obj, defaultMultipartMemory)
+}
+
+func (formBinding) BindMemory(req *http.Request, obj interface{}, maxMemory int64) error {
+	if err := req.ParseMultipartForm(maxMemory); err != nil && err != http.ErrNotMultipart {
+		return err
+	}
+	var files map[string][]*multipart.FileHeader
+	if req.MultipartForm != nil {
+		files = req.MultipartForm.File
+	}
+	return mapValues(obj, req.Form, files, "form", false)
+}
+
+// formPostBinding 仅绑定请求体中的表单参数
+type formPostBinding struct{}
+
+func (formPostBinding) Name() string { return "form-urlencoded" }
+
+func (formPostBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+	return mapValues(obj, req.PostForm, nil, "form", false)
+}
+
+// multipartBinding 绑定 multipart/form-data 中的参数与文件
+type multipartBinding struct{}
+
+func (multipartBinding) Name() string { return "multipart/form-data" }
+
+func (b multipartBinding) Bind(req *http.Request, obj interface{}) error {
+	return b.BindMemory(req, obj, defaultMultipartMemory)
+}
+
+func (multipartBinding) BindMemory(req *http.Request, obj interface{}, maxMemory int64) error {
+	if err := req.ParseMultipartForm(maxMemory); err != nil {
+		return err
+	}
+	return mapValues(obj, req.MultipartForm.Value, req.MultipartForm.File, "form", false)
+}
+
+type queryBinding struct{}
+
+func (queryBinding) Name() string { return "query" }
+
+func (queryBinding) Bind(req *http.Request, obj interface{}) error {
+	return mapValues(obj, req.URL.Query(), nil, "query", false)
+}
+
+type headerBinding struct{}
+
+func (headerBinding) Name() string { return "header" }
+
+func (headerBinding) Bind(req *http.Request, obj interface{}) error {
+	return mapValues(obj, req.Header, nil, "header", true)
+}
+
+type uriBinding struct{}
+
+func (uriBinding) Name() string { return "uri" }
+
+func (uriBinding) BindURI(params Params, obj interface{}) error {
+	values := make(map[string][]string, len(params))
+	for _, p := range params {
+		values[p.Key] = append(values[p.Key], p.Value)
+	}
+	return mapValues(obj, values, nil, "uri", false)
+}
+
+/****************************
+ * 结构体映射
+ ****************************/
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+)
+
+// mapping 按结构体标签将键值对映射到结构体字段
+type mapping struct {
+	values    map[string][]string
+	files     map[string][]*multipart.FileHeader
+	tag       string
+	canonical bool // 键是否需要转换为 MIME 规范格式, 用于请求头
+}
+
+// mapValues 按 tag 标签将 values 与 files 映射到 obj 中
+// 标签格式为 `form:"name,default=value"`, 未设置标签时使用字段名, 标签为 - 时忽略该字段
+func mapValues(obj interface{}, values map[string][]string, files map[string][]*multipart.FileHeader, tag string, canonical bool) error {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("zero: bind target must be a non-nil pointer, got %T", obj)
+	}
+	v = v.Elem()
+	if v.Kind() == reflect.Map {
+		return mapToMap(v, values)
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("zero: bind target must point to a struct or map[string]string, got %T", obj)
+	}
+	m := &mapping{values: values, files: files, tag: tag, canonical: canonical}
+	return m.mapStruct(v)
+}
+
+// mapToMap 映射到 map[string]string 或 map[string][]string
+func mapToMap(v reflect.Value, values map[string][]string) error {
+	t := v.Type()
+	if t.Key().Kind() != reflect.String {
+		return fmt.Errorf("zero: cannot bind to %s", t)
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(t))
+	}
+	for key, vs := range values {
+		switch {
+		case t.Elem().Kind() == reflect.String && len(vs) > 0:
+			v.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(vs[len(vs)-1]))
+		case t.Elem() == reflect.TypeOf([]string(nil)):
+			v.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(vs))
+		default:
+			return fmt.Errorf("zero: cannot bind to %s", t)
+		}
+	}
+	return nil
+}
+
+// mapStruct 映射结构体的全部字段
+func (m *mapping) mapStruct(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			// 未导出字段
+			continue
+		}
+		if err := m.mapField(v.Field(i), field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapField 映射单个字段
+func (m *mapping) mapField(v reflect.Value, field reflect.StructField) error {
+	tag, ok := field.Tag.Lookup(m.tag)
+	if tag == "-" {
+		return nil
+	}
+	name, defaultValue := tag, ""
+	if i := strings.IndexByte(tag, ','); i >= 0 {
+		name = tag[:i]
+		for _, opt := range strings.Split(tag[i+1:], ",") {
+			if strings.HasPrefix(opt, "default=") {
+				defaultValue = opt[len("default="):]
+			}
+		}
+	}
+
+	t := field.Type
+	if t.Kind() == reflect.Struct && t != timeType && !ok {
+		// 未设置标签的嵌套结构体, 递归映射其字段
+		return m.mapStruct(v)
+	}
+	if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct && t.Elem() != timeType && !ok {
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return m.mapStruct(v.Elem())
+	}
+	if !v.CanSet() {
+		return nil
+	}
+	if name == "" {
+		name = field.Name
+	}
+	if m.canonical {
+		name = textproto.CanonicalMIMEHeaderKey(name)
+	}
+
+	// 文件
+	if t == fileHeaderType || t.Kind() == reflect.Slice && t.Elem() == fileHeaderType {
+		files := m.files[name]
+		if len(files) == 0 {
+			return nil
+		}
+		if t == fileHeaderType {
+			v.Set(reflect.ValueOf(files[0]))
+		} else {
+			v.Set(reflect.ValueOf(files))
+		}
+		return nil
+	}
+
+	values, exist := m.values[name]
+	if !exist || len(values) == 0 {
+		if defaultValue == "" {
+			return nil
+		}
+		values = []string{defaultValue}
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		slice := reflect.MakeSlice(t, len(values), len(values))
+		for i, value := range values {
+			if err := setValue(slice.Index(i), value, field); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+		return nil
+	case reflect.Array:
+		if len(values) != t.Len() {
+			return fmt.Errorf("zero: %q expects %d values, got %d", name, t.Len(), len(values))
+		}
+		for i, value := range values {
+			if err := setValue(v.Index(i), value, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return setValue(v, values[0], field)
+}
+
+// setValue 将字符串转换为字段类型并赋值
+func setValue(v reflect.Value, value string, field reflect.StructField) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.Type() == timeType {
+		return setTime(v, value, field)
+	}
+
+	var err error
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			var d time.Duration
+			if d, err = time.ParseDuration(value); err == nil {
+				v.SetInt(int64(d))
+			}
+			break
+		}
+		var i int64
+		if i, err = strconv.ParseInt(value, 10, v.Type().Bits()); err == nil {
+			v.SetInt(i)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		if u, err = strconv.ParseUint(value, 10, v.Type().Bits()); err == nil {
+			v.SetUint(u)
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(value, v.Type().Bits()); err == nil {
+			v.SetFloat(f)
+		}
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(value); err == nil {
+			v.SetBool(b)
+		}
+	case reflect.Interface:
+		v.Set(reflect.ValueOf(value))
+	default:
+		return fmt.Errorf("zero: unsupported field type %s of %s", v.Type(), field.Name)
+	}
+	if err != nil {
+		return fmt.Errorf("zero: invalid value %q for field %s: %v", value, field.Name, err)
+	}
+	return nil
+}
+
+// setTime 解析时间, 格式由 time_format 标签指定, 默认为 RFC3339
+// time_format 为 unix 或 unixnano 时按时间戳解析
+func setTime(v reflect.Value, value string, field reflect.StructField) error {
+	format := field.Tag.Get("time_format")
+	switch format {
+	case "unix", "unixnano":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("zero: invalid value %q for field %s: %v", value, field.Name, err)
+		}
+		if format == "unix" {
+			v.Set(reflect.ValueOf(time.Unix(n, 0)))
+		} else {
+			v.Set(reflect.ValueOf(time.Unix(0, n)))
+		}
+		return nil
+	case "":
+		format = time.RFC3339
+	}
+	t, err := time.Parse(format, value)
+	if err != nil {
+		return fmt.Errorf("zero: invalid value %q for field %s: %v", value, field.Name, err)
+	}
+	v.Set(reflect.ValueOf(t))
+	return nil
+}
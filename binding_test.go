@@ -0,0 +1,200 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+type bindPage struct {
+	Page int `form:"page,default=1" query:"page,default=1"`
+	Size int `form:"size" query:"size"`
+}
+
+type bindUser struct {
+	bindPage
+	Name     string    `json:"name" xml:"name" form:"name" uri:"name"`
+	ID       uint64    `json:"id" xml:"id" form:"id" uri:"id"`
+	Tags     []string  `form:"tag" query:"tag"`
+	Token    string    `header:"x-token"`
+	Birthday time.Time `form:"birthday" time_format:"2006-01-02"`
+	Ignored  string    `form:"-"`
+}
+
+func newBindContext(method, target, contentType string, body string) *Context {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	c := &Context{}
+	c.reset(httptest.NewRecorder(), req)
+	return c
+}
+
+func TestContext_ShouldBind(t *testing.T) {
+	var u bindUser
+	c := newBindContext("POST", "/", "application/json; charset=utf-8", `{"name":"chenquan","id":7}`)
+	if err := c.ShouldBind(&u); err != nil || u.Name != "chenquan" || u.ID != 7 {
+		t.Fatalf("json: %+v, %v", u, err)
+	}
+
+	u = bindUser{}
+	c = newBindContext("POST", "/", "application/xml", `<user><name>chenquan</name><id>8</id></user>`)
+	if err := c.ShouldBind(&u); err != nil || u.Name != "chenquan" || u.ID != 8 {
+		t.Fatalf("xml: %+v, %v", u, err)
+	}
+
+	u = bindUser{}
+	c = newBindContext("POST", "/?size=20", "application/x-www-form-urlencoded", "name=chenquan&tag=a&tag=b&birthday=2020-06-01&Ignored=x")
+	if err := c.ShouldBind(&u); err != nil {
+		t.Fatal(err)
+	}
+	if u.Name != "chenquan" || u.Page != 1 || u.Size != 20 || len(u.Tags) != 2 || u.Ignored != "" ||
+		!u.Birthday.Equal(time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("form: %+v", u)
+	}
+
+	u = bindUser{}
+	c = newBindContext("GET", "/?name=chenquan&page=3", "", "")
+	if err := c.ShouldBind(&u); err != nil || u.Name != "chenquan" || u.Page != 3 {
+		t.Fatalf("get: %+v, %v", u, err)
+	}
+
+	c = newBindContext("POST", "/", "application/x-www-form-urlencoded", "id=abc")
+	if err := c.ShouldBind(&u); err == nil {
+		t.Fatal("invalid uint should fail")
+	}
+}
+
+func TestContext_BindSources(t *testing.T) {
+	var u bindUser
+	c := newBindContext("GET", "/?tag=a&size=5", "", "")
+	c.Req.Header.Set("X-Token", "secret")
+	if err := c.ShouldBindQuery(&u); err != nil || len(u.Tags) != 1 || u.Size != 5 || u.Page != 1 {
+		t.Fatalf("query: %+v, %v", u, err)
+	}
+	if err := c.ShouldBindHeader(&u); err != nil || u.Token != "secret" {
+		t.Fatalf("header: %+v, %v", u, err)
+	}
+
+	c.Params = Params{{"name", "zero"}, {"id", "9"}}
+	if err := c.ShouldBindURI(&u); err != nil || u.Name != "zero" || u.ID != 9 {
+		t.Fatalf("uri: %+v, %v", u, err)
+	}
+
+	m := map[string]string{}
+	if err := c.ShouldBindQuery(&m); err != nil || m["tag"] != "a" {
+		t.Fatalf("map: %v, %v", m, err)
+	}
+}
+
+func TestContext_BindMultipart(t *testing.T) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	w.WriteField("name", "chenquan")
+	fw, _ := w.CreateFormFile("avatar", "avatar.png")
+	fw.Write([]byte("png"))
+	w.Close()
+
+	var form struct {
+		Name   string                `form:"name"`
+		Avatar *multipart.FileHeader `form:"avatar"`
+	}
+	c := newBindContext("POST", "/", w.FormDataContentType(), body.String())
+	if err := c.ShouldBind(&form); err != nil || form.Name != "chenquan" || form.Avatar == nil || form.Avatar.Filename != "avatar.png" {
+		t.Fatalf("multipart: %+v, %v", form, err)
+	}
+}
+
+func TestContext_BindMultipart_maxMemory(t *testing.T) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	fw, _ := w.CreateFormFile("avatar", "avatar.png")
+	fw.Write([]byte("png"))
+	w.Close()
+
+	var form struct {
+		Avatar *multipart.FileHeader `form:"avatar"`
+	}
+	c := newBindContext("POST", "/", w.FormDataContentType(), body.String())
+	c.engine = New()
+	// 超出 MaxMultipartMemory 的文件写入临时文件
+	c.engine.MaxMultipartMemory = 1
+	if err := c.ShouldBind(&form); err != nil || form.Avatar == nil {
+		t.Fatalf("multipart: %+v, %v", form, err)
+	}
+	defer c.Req.MultipartForm.RemoveAll()
+	f, err := form.Avatar.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, ok := f.(*os.File); !ok {
+		t.Fatal("file should be stored on disk when it exceeds Engine.MaxMultipartMemory")
+	}
+}
+
+type csvBinding struct{}
+
+func (csvBinding) Name() string { return "csv" }
+
+func (csvBinding) Bind(req *http.Request, obj interface{}) error {
+	var buf bytes.Buffer
+	buf.ReadFrom(req.Body)
+	*obj.(*[]string) = strings.Split(buf.String(), ",")
+	return nil
+}
+
+func TestRegisterBinding(t *testing.T) {
+	RegisterBinding("text/csv", csvBinding{})
+	defer func() {
+		bindingsMu.Lock()
+		delete(bindings, "text/csv")
+		bindingsMu.Unlock()
+	}()
+
+	var values []string
+	c := newBindContext("POST", "/", "text/csv", "a,b,c")
+	if err := c.ShouldBind(&values); err != nil || len(values) != 3 {
+		t.Fatalf("csv: %v, %v", values, err)
+	}
+}
+
+func TestContext_Bind_fail(t *testing.T) {
+	engine := New()
+	engine.POST("/users", func(c *Context) {
+		var u bindUser
+		if err := c.BindJSON(&u); err != nil {
+			return
+		}
+		c.String(http.StatusOK, u.Name)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/users", strings.NewReader("{")))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Constraint 路径参数约束, 返回参数值是否满足约束
+type Constraint func(value string) bool
+
+var (
+	constraintsMu sync.RWMutex
+	// constraints 具名参数约束, 例如 /users/:id<int>
+	constraints = map[string]Constraint{
+		"int": func(value string) bool {
+			if !isInteger(value, true) {
+				return false
+			}
+			_, err := strconv.ParseInt(value, 10, 64)
+			return err == nil
+		},
+		"uint": func(value string) bool {
+			if !isInteger(value, false) {
+				return false
+			}
+			_, err := strconv.ParseUint(value, 10, 64)
+			return err == nil
+		},
+		"float": func(value string) bool {
+			_, err := strconv.ParseFloat(value, 64)
+			return err == nil
+		},
+		"bool": func(value string) bool {
+			switch value {
+			case "1", "t", "T", "true", "TRUE", "True", "0", "f", "F", "false", "FALSE", "False":
+				return true
+			}
+			return false
+		},
+		"uuid":  isUUID,
+		"alpha": regexp.MustCompile(`^[a-zA-Z]+$`).MatchString,
+		"alnum": regexp.MustCompile(`^[a-zA-Z0-9]+$`).MatchString,
+	}
+)
+
+// RegisterConstraint 注册具名参数约束, 同名约束会被覆盖
+// 需在注册使用该约束的路由之前调用
+func RegisterConstraint(name string, constraint Constraint) {
+	constraintsMu.Lock()
+	defer constraintsMu.Unlock()
+	constraints[name] = constraint
+}
+
+// compileConstraint 解析约束表达式, 优先使用具名约束, 否则视为正则表达式
+func compileConstraint(expr string) (Constraint, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	constraintsMu.RLock()
+	constraint, ok := constraints[expr]
+	constraintsMu.RUnlock()
+	if ok {
+		return constraint, nil
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString, nil
+}
+
+// splitParam 拆分参数名与约束, 例如 :id<int> 拆分为 id 与 int
+func splitParam(wildcard string) (name string, expr string) {
+	name = wildcard[1:]
+	if i := strings.IndexByte(name, '<'); i >= 0 && strings.HasSuffix(name, ">") {
+		return name[:i], name[i+1 : len(name)-1]
+	}
+	return name, ""
+}
+
+// isInteger 是否为十进制整数, 在调用 strconv 之前过滤, 避免错误路径上的内存分配
+func isInteger(value string, signed bool) bool {
+	if signed && len(value) > 1 && (value[0] == '-' || value[0] == '+') {
+		value = value[1:]
+	}
+	if value == "" {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isUUID 是否为 8-4-4-4-12 格式的 UUID
+func isUUID(value string) bool {
+	if len(value) != 36 {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -18,9 +18,14 @@
 package zero
 
 import (
-	"encoding/json"
-	"fmt"
+	"bytes"
+	"context"
+	"log"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
@@ -29,34 +34,84 @@ type Z map[string]interface{}
 
 type Context struct {
 	// request info
-	Req        *http.Request     // 请求
-	Path       string            //路径
-	Method     string            // 请求方式
-	Params     map[string]string // 路径参数
-	StatusCode int
+	Req        *http.Request // 请求
+	Path       string        //路径
+	Method     string        // 请求方式
+	Params     Params        // 路径参数
+	StatusCode int           // 通过 Status 设置的状态码, 实际写入的状态码见 Res.Status
 	// response info
-	Res http.ResponseWriter //返回
+	Res       ResponseWriter //返回
+	writermem responseWriter // Res 的底层存储, 随上下文复用
 
 	// middleware
 	handlers []HandlerFunc // 中间件
 	index    int           //当前执行的中间件,-1:初始位置
 	engine   *Engine
+	route    *Route // 匹配到的路由, 未匹配时为空
 
 	// 该互斥锁保护Keys map
 	mu sync.RWMutex
 
 	// keys 是专门针对每个请求的上下文的键/值对
 	keys map[string]interface{}
+
+	// Errors 处理请求过程中通过 Error 记录的错误
+	Errors    ErrorList
+	errorsOut int // 已交给 ErrorHandler 输出的错误数
+
+	queryCache url.Values // 缓存解析后的查询参数
+	formCache  url.Values // 缓存解析后的表单参数
+}
+
+// reset 重置上下文以处理新的请求, 保留路径参数切片的容量供复用
+func (c *Context) reset(w http.ResponseWriter, req *http.Request) {
+	c.writermem.reset(w)
+	c.Res = &c.writermem
+	c.Req = req
+	c.Path = req.URL.Path
+	c.Method = req.Method
+	c.Params = c.Params[:0]
+	c.StatusCode = 0
+	c.handlers = nil
+	c.index = -1
+	c.route = nil
+	c.keys = nil
+	c.Errors = c.Errors[:0]
+	c.errorsOut = 0
+	c.queryCache = nil
+	c.formCache = nil
 }
 
-func newContext(w http.ResponseWriter, req *http.Request) *Context {
-	return &Context{
-		Res:    w,
-		Req:    req,
-		Path:   req.URL.Path,
-		Method: req.Method,
-		index:  -1,
+// Copy 返回当前上下文的副本, 用于在处理函数返回后仍需使用上下文的场景(例如新的 goroutine)
+// 上下文会被回收复用, 处理函数返回后不能再使用原上下文
+func (c *Context) Copy() *Context {
+	cp := &Context{
+		Req:        c.Req,
+		Path:       c.Path,
+		Method:     c.Method,
+		StatusCode: c.StatusCode,
+		index:      abortIndex,
+		engine:     c.engine,
+		route:      c.route,
+	}
+	// 副本不能写入响应, 仅保留当前的状态码与响应体大小, 原上下文的 writermem 会被下一个请求复用
+	cp.writermem = responseWriter{size: noWritten, status: http.StatusOK}
+	if c.Res != nil {
+		cp.writermem.size, cp.writermem.status = c.Res.Size(), c.Res.Status()
+	}
+	cp.Res = &cp.writermem
+	cp.Params = append(Params(nil), c.Params...)
+	cp.Errors = append(ErrorList(nil), c.Errors...)
+	cp.errorsOut = len(cp.Errors)
+	c.mu.RLock()
+	if c.keys != nil {
+		cp.keys = make(map[string]interface{}, len(c.keys))
+		for k, v := range c.keys {
+			cp.keys[k] = v
+		}
 	}
+	c.mu.RUnlock()
+	return cp
 }
 
 // Next 执行下一个中间件
@@ -65,14 +120,242 @@ func (c *Context) Next() {
 	length := len(c.handlers)
 	for ; c.index < length; c.index++ {
 		c.handlers[c.index](c)
+		// 处理链执行完毕或已中止时立即输出错误, 外层中间件恢复执行时可获取最终状态码
+		if c.index >= length-1 {
+			c.handleErrors()
+		}
+	}
+}
+
+// handleErrors 调用 Engine.ErrorHandler 输出新记录的错误
+func (c *Context) handleErrors() {
+	if c.pendingErrors() {
+		c.errorsOut = len(c.Errors)
+		c.engine.ErrorHandler(c)
+	}
+}
+
+// pendingErrors 是否有待 ErrorHandler 输出的错误
+func (c *Context) pendingErrors() bool {
+	return len(c.Errors) > c.errorsOut && c.engine != nil && c.engine.ErrorHandler != nil
+}
+
+/****************************
+ * 处理链控制
+ ****************************/
+
+// abortIndex 中止后的处理函数下标, 大于任何处理链的长度
+const abortIndex = math.MaxInt32 / 2
+
+// Abort 中止处理链, 当前处理函数之后的处理函数不再执行, 当前处理函数会继续执行完毕
+// 已执行的中间件中调用 Next 之后的代码仍会执行
+func (c *Context) Abort() {
+	c.index = abortIndex
+}
+
+// IsAborted 处理链是否已中止
+func (c *Context) IsAborted() bool {
+	return c.index >= abortIndex
+}
+
+// AbortWithStatus 写入状态码并中止处理链
+func (c *Context) AbortWithStatus(code int) {
+	c.Status(code)
+	c.Abort()
+}
+
+// AbortWithStatusJSON 写入 JSON 响应并中止处理链
+func (c *Context) AbortWithStatusJSON(code int, obj interface{}) {
+	c.Abort()
+	c.JSON(code, obj)
+}
+
+// AbortWithError 设置状态码、记录错误并中止处理链, 错误由 Engine.ErrorHandler 输出
+func (c *Context) AbortWithError(code int, err error) error {
+	c.AbortWithStatus(code)
+	return c.Error(err)
+}
+
+// Error 记录错误, 处理链执行完毕后由 Engine.ErrorHandler 统一输出, 返回 err 以便调用方继续处理
+func (c *Context) Error(err error) error {
+	if err == nil {
+		panic("zero: err is nil")
+	}
+	c.Errors = append(c.Errors, err)
+	return err
+}
+
+// HandlerName 返回路由处理函数的名称, 即处理链中的最后一个函数
+func (c *Context) HandlerName() string {
+	if len(c.handlers) == 0 {
+		return ""
+	}
+	return nameOfFunction(c.handlers[len(c.handlers)-1])
+}
+
+// HandlerNames 返回处理链中全部函数的名称, 包括中间件
+func (c *Context) HandlerNames() []string {
+	return handlerNames(c.handlers)
+}
+
+// FullPath 返回匹配到的完整路由, 例如 /user/:id, 未匹配到路由时返回空字符串
+func (c *Context) FullPath() string {
+	if c.route == nil {
+		return ""
+	}
+	return c.route.Pattern
+}
+
+/****************************
+ * 查询参数与表单参数
+ ****************************/
+
+// initQueryCache 解析并缓存查询参数
+func (c *Context) initQueryCache() {
+	if c.queryCache == nil {
+		if c.Req != nil {
+			c.queryCache = c.Req.URL.Query()
+		} else {
+			c.queryCache = url.Values{}
+		}
+	}
+}
+
+// Query 返回查询参数, 不存在时返回空字符串
+func (c *Context) Query(key string) string {
+	value, _ := c.GetQuery(key)
+	return value
+}
+
+// DefaultQuery 返回查询参数, 不存在时返回 defaultValue
+func (c *Context) DefaultQuery(key string, defaultValue string) string {
+	if value, ok := c.GetQuery(key); ok {
+		return value
 	}
+	return defaultValue
+}
+
+// GetQuery 返回查询参数及其是否存在, 例如 /?name=&age=1 中 name 存在且为空字符串
+func (c *Context) GetQuery(key string) (string, bool) {
+	if values, ok := c.GetQueryArray(key); ok {
+		return values[0], true
+	}
+	return "", false
+}
+
+// QueryArray 返回查询参数的全部值
+func (c *Context) QueryArray(key string) []string {
+	values, _ := c.GetQueryArray(key)
+	return values
 }
+
+// GetQueryArray 返回查询参数的全部值及其是否存在
+func (c *Context) GetQueryArray(key string) ([]string, bool) {
+	c.initQueryCache()
+	values, ok := c.queryCache[key]
+	return values, ok && len(values) > 0
+}
+
+// QueryMap 返回 key[name]=value 形式的查询参数
+func (c *Context) QueryMap(key string) map[string]string {
+	dicts, _ := c.GetQueryMap(key)
+	return dicts
+}
+
+// GetQueryMap 返回 key[name]=value 形式的查询参数及其是否存在
+func (c *Context) GetQueryMap(key string) (map[string]string, bool) {
+	c.initQueryCache()
+	return parseMap(c.queryCache, key)
+}
+
+// initFormCache 解析并缓存表单参数, 包括 application/x-www-form-urlencoded 与 multipart/form-data
+func (c *Context) initFormCache() {
+	if c.formCache == nil {
+		c.formCache = url.Values{}
+		if c.Req == nil {
+			return
+		}
+		if err := c.Req.ParseMultipartForm(c.maxMultipartMemory()); err != nil && err != http.ErrNotMultipart {
+			log.Printf("[ZERO] error on parse multipart form: %v", err)
+		}
+		if c.Req.PostForm != nil {
+			c.formCache = c.Req.PostForm
+		}
+	}
+}
+
+// maxMultipartMemory 返回解析 multipart/form-data 时存放在内存中的最大字节数
+func (c *Context) maxMultipartMemory() int64 {
+	if c.engine != nil {
+		return c.engine.MaxMultipartMemory
+	}
+	return defaultMultipartMemory
+}
+
+// PostForm 返回表单参数, 不存在时返回空字符串
 func (c *Context) PostForm(key string) string {
-	return c.Req.FormValue(key)
+	value, _ := c.GetPostForm(key)
+	return value
 }
-func (c *Context) Query(key string) {
-	c.Req.URL.Query().Get(key)
+
+// DefaultPostForm 返回表单参数, 不存在时返回 defaultValue
+func (c *Context) DefaultPostForm(key string, defaultValue string) string {
+	if value, ok := c.GetPostForm(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// GetPostForm 返回表单参数及其是否存在
+func (c *Context) GetPostForm(key string) (string, bool) {
+	if values, ok := c.GetPostFormArray(key); ok {
+		return values[0], true
+	}
+	return "", false
+}
+
+// PostFormArray 返回表单参数的全部值
+func (c *Context) PostFormArray(key string) []string {
+	values, _ := c.GetPostFormArray(key)
+	return values
+}
+
+// GetPostFormArray 返回表单参数的全部值及其是否存在
+func (c *Context) GetPostFormArray(key string) ([]string, bool) {
+	c.initFormCache()
+	values, ok := c.formCache[key]
+	return values, ok && len(values) > 0
+}
+
+// PostFormMap 返回 key[name]=value 形式的表单参数
+func (c *Context) PostFormMap(key string) map[string]string {
+	dicts, _ := c.GetPostFormMap(key)
+	return dicts
+}
+
+// GetPostFormMap 返回 key[name]=value 形式的表单参数及其是否存在
+func (c *Context) GetPostFormMap(key string) (map[string]string, bool) {
+	c.initFormCache()
+	return parseMap(c.formCache, key)
 }
+
+// parseMap 解析 key[name]=value 形式的参数
+func parseMap(values url.Values, key string) (map[string]string, bool) {
+	dicts := make(map[string]string)
+	exist := false
+	for k, v := range values {
+		i := strings.IndexByte(k, '[')
+		if i < 1 || k[:i] != key || len(v) == 0 {
+			continue
+		}
+		if j := strings.IndexByte(k[i+1:], ']'); j >= 1 {
+			exist = true
+			dicts[k[i+1:][:j]] = v[0]
+		}
+	}
+	return dicts, exist
+}
+
 func (c *Context) Status(statusCode int) {
 	c.StatusCode = statusCode
 	c.Res.WriteHeader(statusCode)
@@ -80,41 +363,222 @@ func (c *Context) Status(statusCode int) {
 func (c *Context) SetHeader(key string, value string) {
 	c.Res.Header().Set(key, value)
 }
-func (c *Context) String(code int, format string, values ...interface{}) {
-	c.SetHeader("Content-Type", "text/plain")
+
+/****************************
+ * 响应渲染
+ ****************************/
+
+// Render 使用渲染器写入响应, 渲染失败时返回 500, 开启 Engine.ProblemDetails 时输出问题详情
+func (c *Context) Render(code int, r Render) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer putBuffer(buf)
+	if err := r.Render(buf); err != nil {
+		log.Printf("[ZERO] render error: %v", err)
+		c.Res.Header().Del("Content-Length")
+		// 问题详情本身渲染失败时回退为文本, 避免递归
+		if _, ok := r.(RenderProblem); !ok && c.problemDetails() {
+			c.Problem(NewProblem(http.StatusInternalServerError, ""))
+			return
+		}
+		c.SetHeader("Content-Type", "text/plain; charset=utf-8")
+		c.Status(http.StatusInternalServerError)
+		c.Res.Write([]byte(http.StatusText(http.StatusInternalServerError)))
+		return
+	}
+
+	if contentType := r.ContentType(); contentType != "" {
+		c.SetHeader("Content-Type", contentType)
+	}
 	c.Status(code)
-	c.Res.Write([]byte(fmt.Sprintf(format, values...)))
+	if !bodyAllowedForStatus(code) || c.Method == HEAD {
+		return
+	}
+	c.Res.Write(buf.Bytes())
 }
+
+// String 写入文本响应
+func (c *Context) String(code int, format string, values ...interface{}) {
+	c.Render(code, RenderString{Format: format, Values: values})
+}
+
+// JSON 写入 JSON 响应
 func (c *Context) JSON(code int, obj interface{}) {
-	c.SetHeader("Content-Type", "application/json")
-	c.Status(code)
-	encoder := json.NewEncoder(c.Res)
-	if err := encoder.Encode(obj); err != nil {
-		http.Error(c.Res, err.Error(), 500)
+	c.Render(code, RenderJSON{Data: obj})
+}
+
+// IndentedJSON 写入带缩进的 JSON 响应, 便于阅读
+func (c *Context) IndentedJSON(code int, obj interface{}) {
+	c.Render(code, RenderIndentedJSON{Data: obj})
+}
+
+// SecureJSON 写入 JSON 响应, 内容为数组时添加 while(1); 前缀以防止 JSON 劫持
+func (c *Context) SecureJSON(code int, obj interface{}) {
+	c.Render(code, RenderSecureJSON{Prefix: defaultSecureJSONPrefix, Data: obj})
+}
+
+// JSONP 写入 JSONP 响应, 回调函数名取自查询参数 callback, 不存在时写入 JSON 响应
+func (c *Context) JSONP(code int, obj interface{}) {
+	callback := c.Query("callback")
+	if callback == "" {
+		c.Render(code, RenderJSON{Data: obj})
+		return
 	}
+	c.Render(code, RenderJSONP{Callback: callback, Data: obj})
 }
+
+// AsciiJSON 写入仅包含 ASCII 字符的 JSON 响应
+func (c *Context) AsciiJSON(code int, obj interface{}) {
+	c.Render(code, RenderAsciiJSON{Data: obj})
+}
+
+// PureJSON 写入不转义 HTML 字符的 JSON 响应
+func (c *Context) PureJSON(code int, obj interface{}) {
+	c.Render(code, RenderPureJSON{Data: obj})
+}
+
+// XML 写入 XML 响应
+func (c *Context) XML(code int, obj interface{}) {
+	c.Render(code, RenderXML{Data: obj})
+}
+
+// YAML 写入 YAML 响应
+func (c *Context) YAML(code int, obj interface{}) {
+	c.Render(code, RenderYAML{Data: obj})
+}
+
+// TOML 写入 TOML 响应
+func (c *Context) TOML(code int, obj interface{}) {
+	c.Render(code, RenderTOML{Data: obj})
+}
+
+// Data 写入二进制响应, Content-Type 由 net/http 根据内容推断
 func (c *Context) Data(code int, data []byte) {
-	c.Status(code)
-	c.Res.Write(data)
+	c.Render(code, RenderData{Data: data})
+}
+
+// DataWithType 写入指定 Content-Type 的二进制响应
+func (c *Context) DataWithType(code int, contentType string, data []byte) {
+	c.Render(code, RenderData{ContentTypeValue: contentType, Data: data})
 }
 
 func (c *Context) Param(key string) string {
-	value, _ := c.Params[key]
-	return value
+	return c.Params.ByName(key)
+}
+
+// Route 返回匹配到的路由, 未匹配到路由时返回 nil
+func (c *Context) Route() *Route {
+	return c.route
+}
+
+// Metadata 返回匹配到的路由的元数据, 未匹配到路由时返回零值
+func (c *Context) Metadata() RouteMeta {
+	if c.route == nil {
+		return RouteMeta{}
+	}
+	return c.route.Metadata()
+}
+
+// param 返回路径参数, 不存在时返回 *ParamError
+func (c *Context) param(key string, typ string) (string, error) {
+	value, ok := c.Params.Get(key)
+	if !ok {
+		return "", &ParamError{Key: key, Type: typ}
+	}
+	return value, nil
+}
+
+// ParamInt 返回整型路径参数
+func (c *Context) ParamInt(key string) (int, error) {
+	i64, err := c.paramInt(key, "int", strconv.IntSize)
+	return int(i64), err
+}
+
+// ParamInt64 返回64位整型路径参数
+func (c *Context) ParamInt64(key string) (int64, error) {
+	return c.paramInt(key, "int64", 64)
+}
+
+func (c *Context) paramInt(key string, typ string, bitSize int) (int64, error) {
+	value, err := c.param(key, typ)
+	if err != nil {
+		return 0, err
+	}
+	i64, err := strconv.ParseInt(value, 10, bitSize)
+	if err != nil {
+		return 0, &ParamError{Key: key, Value: value, Type: typ, Err: err}
+	}
+	return i64, nil
 }
 
-// Fail 错误信息
+// ParamUint 返回无符号整型路径参数
+func (c *Context) ParamUint(key string) (uint, error) {
+	ui64, err := c.paramUint(key, "uint", strconv.IntSize)
+	return uint(ui64), err
+}
+
+// ParamUint64 返回无符号64位整型路径参数
+func (c *Context) ParamUint64(key string) (uint64, error) {
+	return c.paramUint(key, "uint64", 64)
+}
+
+func (c *Context) paramUint(key string, typ string, bitSize int) (uint64, error) {
+	value, err := c.param(key, typ)
+	if err != nil {
+		return 0, err
+	}
+	ui64, err := strconv.ParseUint(value, 10, bitSize)
+	if err != nil {
+		return 0, &ParamError{Key: key, Value: value, Type: typ, Err: err}
+	}
+	return ui64, nil
+}
+
+// ParamFloat64 返回双精度浮点数路径参数
+func (c *Context) ParamFloat64(key string) (float64, error) {
+	value, err := c.param(key, "float64")
+	if err != nil {
+		return 0, err
+	}
+	f64, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, &ParamError{Key: key, Value: value, Type: "float64", Err: err}
+	}
+	return f64, nil
+}
+
+// ParamBool 返回布尔型路径参数
+func (c *Context) ParamBool(key string) (bool, error) {
+	value, err := c.param(key, "bool")
+	if err != nil {
+		return false, err
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, &ParamError{Key: key, Value: value, Type: "bool", Err: err}
+	}
+	return b, nil
+}
+
+// ParamUUID 返回 UUID 格式的路径参数
+func (c *Context) ParamUUID(key string) (string, error) {
+	value, err := c.param(key, "uuid")
+	if err != nil {
+		return "", err
+	}
+	if !isUUID(value) {
+		return "", &ParamError{Key: key, Value: value, Type: "uuid"}
+	}
+	return value, nil
+}
+
+// Fail 写入 JSON 格式的错误信息并中止处理链, 开启 Engine.ProblemDetails 时输出问题详情
 func (c *Context) Fail(code int, err string) {
-	c.index = len(c.handlers)
-	c.JSON(code, Z{"message": err})
+	c.writeError(code, err, nil)
 }
 
+// HTML 渲染 LoadHTMLGlob 加载的模板
 func (c *Context) HTML(code int, name string, data interface{}) {
-	c.SetHeader("Content-Type", "text/html")
-	c.Status(code)
-	if err := c.engine.htmlTemplates.ExecuteTemplate(c.Res, name, data); err != nil {
-		c.Fail(500, err.Error())
-	}
+	c.Render(code, RenderHTML{Template: c.engine.htmlTemplates, Name: name, Data: data})
 }
 
 /****************************
@@ -250,3 +714,161 @@ func (c *Context) GetStringMapStringSlice(key string) (smss map[string][]string,
 	}
 	return
 }
+
+/****************************
+ * 请求绑定
+ ****************************/
+
+// Bind 根据请求方式与 Content-Type 选择绑定器解析请求, 失败时返回 400 并中止后续处理
+func (c *Context) Bind(obj interface{}) error {
+	return c.BindWith(obj, defaultBinding(c.Method, c.Req.Header.Get("Content-Type")))
+}
+
+// BindJSON 以 JSON 格式解析请求体, 失败时返回 400
+func (c *Context) BindJSON(obj interface{}) error {
+	return c.BindWith(obj, BindingJSON)
+}
+
+// BindXML 以 XML 格式解析请求体, 失败时返回 400
+func (c *Context) BindXML(obj interface{}) error {
+	return c.BindWith(obj, BindingXML)
+}
+
+// BindQuery 解析查询参数, 失败时返回 400
+func (c *Context) BindQuery(obj interface{}) error {
+	return c.BindWith(obj, BindingQuery)
+}
+
+// BindHeader 解析请求头, 失败时返回 400
+func (c *Context) BindHeader(obj interface{}) error {
+	return c.BindWith(obj, BindingHeader)
+}
+
+// BindForm 解析查询参数与表单参数, 失败时返回 400
+func (c *Context) BindForm(obj interface{}) error {
+	return c.BindWith(obj, BindingForm)
+}
+
+// BindURI 解析路径参数, 失败时返回 400
+func (c *Context) BindURI(obj interface{}) error {
+	if err := c.ShouldBindURI(obj); err != nil {
+		c.failBind(err)
+		return err
+	}
+	return nil
+}
+
+// BindWith 使用指定绑定器解析请求, 失败时返回 400
+func (c *Context) BindWith(obj interface{}, b Binding) error {
+	if err := c.ShouldBindWith(obj, b); err != nil {
+		c.failBind(err)
+		return err
+	}
+	return nil
+}
+
+// failBind 返回 400, 校验错误时同时返回各字段的错误
+func (c *Context) failBind(err error) {
+	if errs, ok := err.(ValidationErrors); ok {
+		c.writeError(http.StatusBadRequest, "validation failed", Z{"errors": errs})
+		return
+	}
+	c.Fail(http.StatusBadRequest, err.Error())
+}
+
+// ShouldBind 根据请求方式与 Content-Type 选择绑定器解析并校验请求, 失败时仅返回错误
+func (c *Context) ShouldBind(obj interface{}) error {
+	return c.ShouldBindWith(obj, defaultBinding(c.Method, c.Req.Header.Get("Content-Type")))
+}
+
+// ShouldBindJSON 以 JSON 格式解析请求体
+func (c *Context) ShouldBindJSON(obj interface{}) error {
+	return c.ShouldBindWith(obj, BindingJSON)
+}
+
+// ShouldBindXML 以 XML 格式解析请求体
+func (c *Context) ShouldBindXML(obj interface{}) error {
+	return c.ShouldBindWith(obj, BindingXML)
+}
+
+// ShouldBindQuery 解析查询参数
+func (c *Context) ShouldBindQuery(obj interface{}) error {
+	return c.ShouldBindWith(obj, BindingQuery)
+}
+
+// ShouldBindHeader 解析请求头
+func (c *Context) ShouldBindHeader(obj interface{}) error {
+	return c.ShouldBindWith(obj, BindingHeader)
+}
+
+// ShouldBindForm 解析查询参数与表单参数
+func (c *Context) ShouldBindForm(obj interface{}) error {
+	return c.ShouldBindWith(obj, BindingForm)
+}
+
+// ShouldBindURI 解析并校验路径参数
+func (c *Context) ShouldBindURI(obj interface{}) error {
+	if err := BindingURI.BindURI(c.Params, obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+// ShouldBindWith 使用指定绑定器解析请求, 并使用 DefaultValidator 校验
+// 校验未通过时返回 ValidationErrors
+func (c *Context) ShouldBindWith(obj interface{}, b Binding) error {
+	var err error
+	if mb, ok := b.(memoryBinding); ok {
+		err = mb.BindMemory(c.Req, obj, c.maxMultipartMemory())
+	} else {
+		err = b.Bind(c.Req, obj)
+	}
+	if err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+/****************************
+ * context.Context
+ ****************************/
+
+var _ context.Context = (*Context)(nil)
+
+// Deadline 返回请求上下文的截止时间
+// Context 会被复用, 需要在处理函数返回后继续使用时请传入 Copy 的返回值
+func (c *Context) Deadline() (deadline time.Time, ok bool) {
+	if c.Req == nil {
+		return
+	}
+	return c.Req.Context().Deadline()
+}
+
+// Done 返回请求上下文的 Done 通道, 客户端断开连接或请求处理完毕时关闭
+func (c *Context) Done() <-chan struct{} {
+	if c.Req == nil {
+		return nil
+	}
+	return c.Req.Context().Done()
+}
+
+// Err 返回请求上下文被取消的原因
+func (c *Context) Err() error {
+	if c.Req == nil {
+		return nil
+	}
+	return c.Req.Context().Err()
+}
+
+// Value 返回与 key 关联的值, 字符串类型的 key 优先从 Set 设置的键值对中查找, 其次查找请求上下文
+func (c *Context) Value(key interface{}) interface{} {
+	if k, ok := key.(string); ok {
+		if value, exists := c.Get(k); exists {
+			return value
+		}
+	}
+	if c.Req == nil {
+		return nil
+	}
+	return c.Req.Context().Value(key)
+}
@@ -0,0 +1,222 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestContext_ParamTyped(t *testing.T) {
+	c := &Context{Params: Params{
+		{"id", "42"},
+		{"neg", "-7"},
+		{"price", "9.5"},
+		{"ok", "true"},
+		{"uuid", "0b7a9c3e-1f2d-4e5f-8a9b-0c1d2e3f4a5b"},
+		{"name", "chenquan"},
+	}}
+
+	if i, err := c.ParamInt("id"); err != nil || i != 42 {
+		t.Fatalf("ParamInt = %d, %v", i, err)
+	}
+	if i64, err := c.ParamInt64("neg"); err != nil || i64 != -7 {
+		t.Fatalf("ParamInt64 = %d, %v", i64, err)
+	}
+	if ui, err := c.ParamUint("id"); err != nil || ui != 42 {
+		t.Fatalf("ParamUint = %d, %v", ui, err)
+	}
+	if _, err := c.ParamUint64("neg"); err == nil {
+		t.Fatal("ParamUint64 should reject negative values")
+	}
+	if f64, err := c.ParamFloat64("price"); err != nil || f64 != 9.5 {
+		t.Fatalf("ParamFloat64 = %v, %v", f64, err)
+	}
+	if b, err := c.ParamBool("ok"); err != nil || !b {
+		t.Fatalf("ParamBool = %v, %v", b, err)
+	}
+	if u, err := c.ParamUUID("uuid"); err != nil || u != "0b7a9c3e-1f2d-4e5f-8a9b-0c1d2e3f4a5b" {
+		t.Fatalf("ParamUUID = %q, %v", u, err)
+	}
+
+	_, err := c.ParamInt("name")
+	if pe, ok := err.(*ParamError); !ok || pe.Key != "name" || pe.Value != "chenquan" || pe.Err == nil {
+		t.Fatalf("ParamInt error = %v", err)
+	}
+	if _, err := c.ParamUUID("name"); err == nil {
+		t.Fatal("ParamUUID should reject invalid values")
+	}
+	if _, err := c.ParamInt("missing"); err == nil || err.Error() != `path parameter "missing" does not exist` {
+		t.Fatalf("missing parameter error = %v", err)
+	}
+}
+
+func TestContext_Query(t *testing.T) {
+	c := &Context{}
+	c.reset(httptest.NewRecorder(), httptest.NewRequest("GET", "/?name=chenquan&empty=&tag=a&tag=b&filter[name]=x&filter[age]=18", nil))
+
+	if got := c.Query("name"); got != "chenquan" {
+		t.Fatalf("Query = %q", got)
+	}
+	if got, ok := c.GetQuery("empty"); !ok || got != "" {
+		t.Fatalf("GetQuery(empty) = %q, %v", got, ok)
+	}
+	if _, ok := c.GetQuery("missing"); ok {
+		t.Fatal("GetQuery(missing) should not exist")
+	}
+	if got := c.DefaultQuery("missing", "def"); got != "def" {
+		t.Fatalf("DefaultQuery = %q", got)
+	}
+	if got := c.DefaultQuery("empty", "def"); got != "" {
+		t.Fatalf("DefaultQuery(empty) = %q", got)
+	}
+	if got := c.QueryArray("tag"); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Fatalf("QueryArray = %v", got)
+	}
+	if got, ok := c.GetQueryMap("filter"); !ok || len(got) != 2 || got["name"] != "x" || got["age"] != "18" {
+		t.Fatalf("GetQueryMap = %v, %v", got, ok)
+	}
+	if _, ok := c.GetQueryMap("name"); ok {
+		t.Fatal("GetQueryMap(name) should not exist")
+	}
+
+	// 重置后不再使用旧的缓存
+	c.reset(httptest.NewRecorder(), httptest.NewRequest("GET", "/?name=zero", nil))
+	if got := c.Query("name"); got != "zero" {
+		t.Fatalf("Query after reset = %q", got)
+	}
+}
+
+func TestContext_PostForm(t *testing.T) {
+	req := httptest.NewRequest("POST", "/?query=1", strings.NewReader("name=chenquan&empty=&tag=a&tag=b&user[id]=1&user[role]=admin"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	c := &Context{}
+	c.reset(httptest.NewRecorder(), req)
+
+	if got := c.PostForm("name"); got != "chenquan" {
+		t.Fatalf("PostForm = %q", got)
+	}
+	if got := c.PostForm("query"); got != "" {
+		t.Fatalf("PostForm should not read query parameters, got %q", got)
+	}
+	if got, ok := c.GetPostForm("empty"); !ok || got != "" {
+		t.Fatalf("GetPostForm(empty) = %q, %v", got, ok)
+	}
+	if got := c.DefaultPostForm("missing", "def"); got != "def" {
+		t.Fatalf("DefaultPostForm = %q", got)
+	}
+	if got := c.PostFormArray("tag"); len(got) != 2 || got[1] != "b" {
+		t.Fatalf("PostFormArray = %v", got)
+	}
+	if got := c.PostFormMap("user"); len(got) != 2 || got["id"] != "1" || got["role"] != "admin" {
+		t.Fatalf("PostFormMap = %v", got)
+	}
+}
+
+func TestContext_Abort(t *testing.T) {
+	engine := New()
+	var trace []string
+	auth := func(c *Context) {
+		trace = append(trace, "auth")
+		if c.Query("token") == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, Z{"message": "unauthorized"})
+			return
+		}
+		c.Next()
+		trace = append(trace, "auth after, aborted="+strconv.FormatBool(c.IsAborted()))
+	}
+	engine.GET("/users/:id", auth, func(c *Context) {
+		trace = append(trace, "handler "+c.FullPath()+" "+c.HandlerName())
+		c.AbortWithStatus(http.StatusTeapot)
+	})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+	if w.Code != http.StatusUnauthorized || strings.Join(trace, ";") != "auth" {
+		t.Fatalf("got %d, trace %v", w.Code, trace)
+	}
+
+	trace = nil
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/users/1?token=x", nil))
+	want := "auth;handler /users/:id github.com/chenquan/zero.TestContext_Abort.func2;auth after, aborted=true"
+	if w.Code != http.StatusTeapot || strings.Join(trace, ";") != want {
+		t.Fatalf("got %d, trace %v", w.Code, trace)
+	}
+}
+
+func TestContext_HandlerNames(t *testing.T) {
+	engine := New()
+	engine.Use(Recovery())
+	var names []string
+	engine.GET("/", func(c *Context) {
+		names = c.HandlerNames()
+	})
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if len(names) != 2 || names[0] != "github.com/chenquan/zero.Recovery.func1" {
+		t.Fatalf("HandlerNames = %v", names)
+	}
+
+	c := &Context{}
+	c.reset(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if c.FullPath() != "" || c.HandlerName() != "" || c.IsAborted() {
+		t.Fatal("unmatched context should have no route information")
+	}
+	if cp := c.Copy(); !cp.IsAborted() {
+		t.Fatal("copied context should not run the chain")
+	}
+}
+
+func TestContext_contextContext(t *testing.T) {
+	parent, cancel := context.WithTimeout(context.WithValue(context.Background(), ctxKey("request"), "from request"), time.Minute)
+	req := httptest.NewRequest("GET", "/", nil).WithContext(parent)
+	c := &Context{}
+	c.reset(httptest.NewRecorder(), req)
+	c.Set("user", "chenquan")
+
+	var ctx context.Context = c
+	if deadline, ok := ctx.Deadline(); !ok || deadline.IsZero() {
+		t.Fatal("Deadline should come from the request context")
+	}
+	if ctx.Value("user") != "chenquan" || ctx.Value(ctxKey("request")) != "from request" || ctx.Value("missing") != nil {
+		t.Fatal("Value should read the keys store and then the request context")
+	}
+	if ctx.Err() != nil {
+		t.Fatal("context should not be canceled yet")
+	}
+
+	cancel()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done should be closed when the request context is canceled")
+	}
+	if ctx.Err() != context.Canceled {
+		t.Fatalf("Err = %v", ctx.Err())
+	}
+
+	empty := &Context{}
+	if _, ok := empty.Deadline(); ok || empty.Done() != nil || empty.Err() != nil || empty.Value("x") != nil {
+		t.Fatal("context without a request should behave like context.Background")
+	}
+}
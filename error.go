@@ -17,8 +17,175 @@
 
 package zero
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+)
 
 var (
-	NotFoundError = errors.New("404 NOT FOUND")
+	NotFoundError         = errors.New("404 NOT FOUND")
+	MethodNotAllowedError = errors.New("405 METHOD NOT ALLOWED")
+	NotAcceptableError    = errors.New("406 NOT ACCEPTABLE")
 )
+
+// RouteError 路由注册错误, 包括格式错误、重复路由与冲突路由
+type RouteError struct {
+	Method   string // 请求方式
+	Pattern  string // 注册的路由
+	Conflict string // 与之冲突的已注册路由, 格式错误时为空
+	Reason   string // 错误原因
+}
+
+func (e *RouteError) Error() string {
+	if e.Conflict == "" {
+		return fmt.Sprintf("invalid route %s %s: %s", e.Method, e.Pattern, e.Reason)
+	}
+	return fmt.Sprintf("route %s %s conflicts with %s %s: %s", e.Method, e.Pattern, e.Method, e.Conflict, e.Reason)
+}
+
+// ParamError 路径参数不存在或无法转换为指定类型
+type ParamError struct {
+	Key   string // 参数名
+	Value string // 参数值
+	Type  string // 目标类型
+	Err   error  // 转换错误, 参数不存在时为空
+}
+
+func (e *ParamError) Error() string {
+	if e.Err == nil && e.Value == "" {
+		return fmt.Sprintf("path parameter %q does not exist", e.Key)
+	}
+	return fmt.Sprintf("path parameter %s=%q is not a valid %s", e.Key, e.Value, e.Type)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// HTTPError 带状态码的错误, 通过 Context.Error 记录后由 Engine.ErrorHandler 统一输出
+type HTTPError struct {
+	Status   int                    // HTTP 状态码
+	Code     string                 // 业务错误码
+	Message  string                 // 返回给客户端的错误描述, 为空时使用状态码的描述
+	Internal error                  // 内部错误, 仅用于日志, 不返回给客户端
+	Meta     map[string]interface{} // 附加信息, 会返回给客户端
+}
+
+// NewHTTPError 创建 HTTPError, message 为空时使用状态码的描述
+func NewHTTPError(status int, message string) *HTTPError {
+	return &HTTPError{Status: status, Message: message}
+}
+
+func (e *HTTPError) Error() string {
+	msg := e.message()
+	if e.Code != "" {
+		msg = e.Code + ": " + msg
+	}
+	if e.Internal != nil {
+		msg += ": " + e.Internal.Error()
+	}
+	return fmt.Sprintf("%d %s", e.Status, msg)
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Internal
+}
+
+// message 返回给客户端的错误描述
+func (e *HTTPError) message() string {
+	if e.Message == "" {
+		return http.StatusText(e.Status)
+	}
+	return e.Message
+}
+
+// WithCode 返回设置了业务错误码的副本
+func (e *HTTPError) WithCode(code string) *HTTPError {
+	cp := *e
+	cp.Code = code
+	return &cp
+}
+
+// WithInternal 返回设置了内部错误的副本
+func (e *HTTPError) WithInternal(err error) *HTTPError {
+	cp := *e
+	cp.Internal = err
+	return &cp
+}
+
+// WithMeta 返回添加了附加信息的副本
+func (e *HTTPError) WithMeta(key string, value interface{}) *HTTPError {
+	cp := *e
+	cp.Meta = make(map[string]interface{}, len(e.Meta)+1)
+	for k, v := range e.Meta {
+		cp.Meta[k] = v
+	}
+	cp.Meta[key] = value
+	return &cp
+}
+
+// ErrorList 处理请求过程中记录的错误
+type ErrorList []error
+
+// Last 返回最后记录的错误, 没有错误时返回 nil
+func (list ErrorList) Last() error {
+	if len(list) == 0 {
+		return nil
+	}
+	return list[len(list)-1]
+}
+
+// String 返回全部错误, 每行一个
+func (list ErrorList) String() string {
+	var b strings.Builder
+	for i, err := range list {
+		fmt.Fprintf(&b, "Error #%02d: %v\n", i+1, err)
+	}
+	return b.String()
+}
+
+// DefaultErrorHandler 默认的错误处理函数, 根据最后记录的错误写入 JSON 响应
+// 响应已写入时仅记录日志; *HTTPError 使用其状态码与描述, 其他错误使用已设置的状态码,
+// 未设置状态码时返回 500, 5xx 错误不会向客户端暴露错误详情
+// 开启 Engine.ProblemDetails 时输出问题详情, 错误码与附加信息作为扩展成员
+func DefaultErrorHandler(c *Context) {
+	err := c.Errors.Last()
+	status, message, extensions := errorResponse(c, err)
+	if status >= http.StatusInternalServerError {
+		log.Printf("[ZERO] %s %s: %v", c.Method, c.Path, err)
+	}
+	if c.Res.Written() {
+		return
+	}
+	c.writeError(status, message, extensions)
+}
+
+// errorResponse 返回错误对应的状态码、描述与附加字段
+func errorResponse(c *Context, err error) (int, string, Z) {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		var extensions Z
+		if he.Code != "" || len(he.Meta) > 0 {
+			extensions = Z{}
+		}
+		if he.Code != "" {
+			extensions["code"] = he.Code
+		}
+		if len(he.Meta) > 0 {
+			extensions["meta"] = he.Meta
+		}
+		return he.Status, he.message(), extensions
+	}
+
+	status := c.Res.Status()
+	if status < http.StatusBadRequest {
+		status = http.StatusInternalServerError
+	}
+	if status >= http.StatusInternalServerError {
+		return status, http.StatusText(status), nil
+	}
+	return status, err.Error(), nil
+}
@@ -0,0 +1,111 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPError(t *testing.T) {
+	cause := errors.New("connection refused")
+	base := NewHTTPError(http.StatusServiceUnavailable, "")
+	err := base.WithCode("db_down").WithInternal(cause).WithMeta("retry", 3)
+
+	if err.Error() != "503 db_down: Service Unavailable: connection refused" {
+		t.Fatalf("Error() = %q", err.Error())
+	}
+	if !errors.Is(err, cause) {
+		t.Fatal("HTTPError should unwrap to its internal error")
+	}
+	if base.Code != "" || base.Internal != nil || base.Meta != nil {
+		t.Fatal("With* should not modify the receiver")
+	}
+
+	var list ErrorList
+	if list.Last() != nil {
+		t.Fatal("Last of an empty list should be nil")
+	}
+	list = append(list, cause, err)
+	if list.Last() != err || !strings.HasPrefix(list.String(), "Error #01: connection refused\n") {
+		t.Fatalf("list = %s", list.String())
+	}
+}
+
+func TestEngine_ErrorHandler(t *testing.T) {
+	engine := New()
+	var seen, status int
+	engine.Use(func(c *Context) {
+		c.Next()
+		// 错误在中间件恢复执行前已输出
+		seen, status = len(c.Errors), c.Res.Status()
+	})
+	engine.GET("/http", func(c *Context) {
+		c.Error(errors.New("ignored"))
+		c.Error(NewHTTPError(http.StatusConflict, "user exists").WithCode("conflict").WithMeta("id", 1))
+	})
+	engine.GET("/abort", func(c *Context) {
+		c.AbortWithError(http.StatusBadRequest, errors.New("bad input"))
+	})
+	engine.GET("/internal", func(c *Context) {
+		c.Error(errors.New("secret dsn"))
+	})
+	engine.GET("/written", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+		c.Error(errors.New("after write"))
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body string
+		errs int
+	}{
+		{"/http", http.StatusConflict, `{"code":"conflict","message":"user exists","meta":{"id":1}}` + "\n", 2},
+		{"/abort", http.StatusBadRequest, `{"message":"bad input"}` + "\n", 1},
+		{"/internal", http.StatusInternalServerError, `{"message":"Internal Server Error"}` + "\n", 1},
+		{"/written", http.StatusOK, "ok", 1},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if w.Code != tt.code || w.Body.String() != tt.body || seen != tt.errs || status != tt.code {
+			t.Errorf("%s: got %d %q, errors %d, middleware status %d", tt.path, w.Code, w.Body.String(), seen, status)
+		}
+	}
+
+	// 复用的上下文不应保留上一个请求的错误
+	engine.GET("/ok", func(c *Context) {
+		c.String(http.StatusOK, "%d", len(c.Errors))
+	})
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/ok", nil))
+	if w.Body.String() != "0" {
+		t.Fatalf("errors leaked between requests: %s", w.Body.String())
+	}
+
+	engine.ErrorHandler = nil
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/internal", nil))
+	if w.Code != http.StatusOK || w.Body.Len() != 0 {
+		t.Fatalf("nil ErrorHandler: got %d %q", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,164 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"net"
+	"strings"
+)
+
+// hostRouter 按域名匹配的路由
+// 域名按 . 分级, 以 : 开头的一级为域名参数, 例如 :tenant.example.com, 参数同样支持约束
+type hostRouter struct {
+	pattern     string       // 域名模式
+	labels      []string     // 域名各级, 参数级为参数名
+	constraints []Constraint // 各级参数约束
+	wild        bool         // 是否包含参数
+	router      *router
+}
+
+// newHostRouter 解析域名模式
+func newHostRouter(pattern string) (*hostRouter, error) {
+	hr := &hostRouter{pattern: pattern, router: newRouter()}
+	for _, label := range strings.Split(pattern, ".") {
+		var constraint Constraint
+		if label != "" && label[0] == ':' {
+			name, expr := splitParam(label)
+			if name == "" {
+				return nil, &RouteError{Pattern: pattern, Reason: "host parameters must be named with a non-empty name"}
+			}
+			c, err := compileConstraint(expr)
+			if err != nil {
+				return nil, &RouteError{Pattern: pattern, Reason: "invalid host constraint " + label + ": " + err.Error()}
+			}
+			hr.wild = true
+			label, constraint = ":"+name, c
+		} else if label == "" {
+			return nil, &RouteError{Pattern: pattern, Reason: "host must not contain empty labels"}
+		} else {
+			// 域名不区分大小写, 参数名保持原样
+			label = strings.ToLower(label)
+		}
+		hr.labels = append(hr.labels, label)
+		hr.constraints = append(hr.constraints, constraint)
+	}
+	return hr, nil
+}
+
+// match 域名是否匹配, 匹配成功时将域名参数追加到 params
+func (hr *hostRouter) match(host string, params *Params) bool {
+	start := len(*params)
+	for i, label := range hr.labels {
+		end := strings.IndexByte(host, '.')
+		last := i == len(hr.labels)-1
+		if last != (end < 0) {
+			// 域名级数不一致
+			break
+		}
+		if last {
+			end = len(host)
+		}
+
+		value := host[:end]
+		if label[0] == ':' {
+			if value == "" || hr.constraints[i] != nil && !hr.constraints[i](value) {
+				break
+			}
+			*params = append(*params, Param{Key: label[1:], Value: value})
+		} else if !strings.EqualFold(label, value) {
+			break
+		}
+
+		if last {
+			return true
+		}
+		host = host[end+1:]
+	}
+	*params = (*params)[:start]
+	return false
+}
+
+// stripHostPort 去除端口号
+func stripHostPort(host string) string {
+	if strings.IndexByte(host, ':') < 0 {
+		return host
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
+
+// hostRouter 返回域名对应的路由, 不存在时创建并发布新的域名列表
+func (e *Engine) hostRouter(pattern string) *hostRouter {
+	hosts, _ := e.hosts.Load().([]*hostRouter)
+	if hr := lookupHostRouter(hosts, pattern); hr != nil {
+		return hr
+	}
+
+	hr, err := newHostRouter(pattern)
+	if err != nil {
+		e.routeError(err)
+		// 使用无法匹配任何域名的路由, 保证注册过程可以继续
+		hr = &hostRouter{pattern: pattern, router: newRouter()}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	// 加锁后重新检查, 避免并发创建
+	hosts, _ = e.hosts.Load().([]*hostRouter)
+	if existing := lookupHostRouter(hosts, pattern); existing != nil {
+		return existing
+	}
+
+	// 精确域名优先于带参数的域名
+	i := len(hosts)
+	if !hr.wild {
+		for i > 0 && hosts[i-1].wild {
+			i--
+		}
+	}
+	newHosts := make([]*hostRouter, 0, len(hosts)+1)
+	newHosts = append(newHosts, hosts[:i]...)
+	newHosts = append(newHosts, hr)
+	newHosts = append(newHosts, hosts[i:]...)
+	e.hosts.Store(newHosts)
+	return hr
+}
+
+// lookupHostRouter 按域名模式查找路由
+func lookupHostRouter(hosts []*hostRouter, pattern string) *hostRouter {
+	for _, hr := range hosts {
+		if hr.pattern == pattern {
+			return hr
+		}
+	}
+	return nil
+}
+
+// Host 返回指定域名的路由分组, 该分组下的路由仅匹配请求域名为 pattern 的请求
+// 域名参数可通过 Context.Param 获取, 例如 engine.Host(":tenant.example.com")
+// 域名路由中没有与请求路径匹配的路由时, 使用默认路由处理, 此时域名参数不可用
+func (e *Engine) Host(pattern string) *RouterGroup {
+	e.hostRouter(pattern)
+	return &RouterGroup{
+		host:   pattern,
+		parent: e.RouterGroup,
+		engine: e,
+	}
+}
@@ -0,0 +1,93 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEngine_Host(t *testing.T) {
+	engine := New()
+	var calls []string
+	engine.Use(func(c *Context) {
+		calls = append(calls, "global")
+		c.Next()
+	})
+	engine.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "default")
+	})
+	engine.GET("/health", func(c *Context) {
+		c.String(http.StatusOK, "ok%s", c.Param("tenant"))
+	})
+
+	api := engine.Host("api.example.com")
+	api.Use(func(c *Context) {
+		calls = append(calls, "api")
+		c.Next()
+	})
+	api.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "api")
+	})
+
+	tenant := engine.Host(":tenant<alnum>.example.com").Group("/users")
+	tenant.GET("/:id", func(c *Context) {
+		c.String(http.StatusOK, "%s:%s", c.Param("tenant"), c.Param("id"))
+	})
+
+	engine.Host(":tenantID.Example.ORG").GET("/", func(c *Context) {
+		c.String(http.StatusOK, "tenant=%s", c.Param("tenantID"))
+	})
+
+	tests := []struct {
+		host  string
+		path  string
+		code  int
+		body  string
+		calls int
+	}{
+		{"api.example.com", "/", http.StatusOK, "api", 2},
+		{"API.example.com:8080", "/", http.StatusOK, "api", 2},
+		{"acme.example.com", "/users/7", http.StatusOK, "acme:7", 1},
+		{"acme.example.com", "/", http.StatusOK, "default", 1},
+		{"acme.example.com", "/health", http.StatusOK, "ok", 1},
+		{"api.example.com", "/health", http.StatusOK, "ok", 1},
+		{"acme.example.com", "/missing", http.StatusNotFound, NotFoundError.Error(), 1},
+		{"a-b.example.com", "/", http.StatusOK, "default", 1},
+		{"x.acme.example.com", "/", http.StatusOK, "default", 1},
+		{"example.com", "/", http.StatusOK, "default", 1},
+		{"Acme.example.org", "/", http.StatusOK, "tenant=Acme", 1},
+	}
+	for _, tt := range tests {
+		calls = nil
+		req := httptest.NewRequest(GET, tt.path, nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != tt.code || w.Body.String() != tt.body || len(calls) != tt.calls {
+			t.Fatalf("%s%s: status = %d, body = %q, middlewares = %v", tt.host, tt.path, w.Code, w.Body.String(), calls)
+		}
+	}
+
+	routes := engine.Routes()
+	if len(routes) != 5 || routes[0].Host != "" || routes[1].Host != "" || routes[2].Host != ":tenant<alnum>.example.com" ||
+		routes[3].Host != ":tenantID.Example.ORG" || routes[4].Host != "api.example.com" {
+		t.Fatalf("Routes() = %+v", routes)
+	}
+}
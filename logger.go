@@ -26,6 +26,6 @@ func LoggerDefault() HandlerFunc {
 	return func(c *Context) {
 		t := time.Now()
 		c.Next()
-		log.Printf("[ZERO] URL [%d] %s in %v", c.StatusCode, c.Req.RequestURI, time.Since(t))
+		log.Printf("[ZERO] URL [%d] %s in %v", c.Res.Status(), c.Req.RequestURI, time.Since(t))
 	}
 }
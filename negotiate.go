@@ -0,0 +1,216 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 常用的 MIME 类型
+const (
+	MIMEJSON  = "application/json"
+	MIMEXML   = "application/xml"
+	MIMEXML2  = "text/xml"
+	MIMEYAML  = "application/x-yaml"
+	MIMETOML  = "application/toml"
+	MIMEHTML  = "text/html"
+	MIMEPlain = "text/plain"
+)
+
+// Negotiate 内容协商参数
+type Negotiate struct {
+	Offered  []string    // 可提供的 MIME 类型, 按优先级排列
+	HTMLName string      // text/html 使用的模板名称
+	HTMLData interface{} // text/html 使用的数据, 为空时使用 Data
+	JSONData interface{} // application/json 使用的数据, 为空时使用 Data
+	XMLData  interface{} // application/xml 使用的数据, 为空时使用 Data
+	YAMLData interface{} // application/x-yaml 使用的数据, 为空时使用 Data
+	TOMLData interface{} // application/toml 使用的数据, 为空时使用 Data
+	Data     interface{} // 默认数据
+}
+
+// data 返回 MIME 类型对应的数据
+func (n Negotiate) data(mime string) interface{} {
+	var data interface{}
+	switch mime {
+	case MIMEHTML:
+		data = n.HTMLData
+	case MIMEJSON:
+		data = n.JSONData
+	case MIMEXML, MIMEXML2:
+		data = n.XMLData
+	case MIMEYAML:
+		data = n.YAMLData
+	case MIMETOML:
+		data = n.TOMLData
+	}
+	if data == nil {
+		return n.Data
+	}
+	return data
+}
+
+// RenderFactory 根据数据创建渲染器
+type RenderFactory func(data interface{}) Render
+
+var (
+	renderersMu sync.RWMutex
+	// renderers 内容协商可选择的渲染器, text/html 由 Context 的模板单独处理
+	renderers = map[string]RenderFactory{
+		MIMEJSON:  func(data interface{}) Render { return RenderJSON{Data: data} },
+		MIMEXML:   func(data interface{}) Render { return RenderXML{Data: data} },
+		MIMEXML2:  func(data interface{}) Render { return RenderXML{Data: data} },
+		MIMEYAML:  func(data interface{}) Render { return RenderYAML{Data: data} },
+		MIMETOML:  func(data interface{}) Render { return RenderTOML{Data: data} },
+		MIMEPlain: func(data interface{}) Render { return RenderString{Format: "%v", Values: []interface{}{data}} },
+	}
+)
+
+// RegisterRenderer 注册内容协商使用的渲染器, 已存在时覆盖
+func RegisterRenderer(mime string, factory RenderFactory) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers[strings.ToLower(mime)] = factory
+}
+
+// lookupRenderer 返回 MIME 类型对应的渲染器
+func lookupRenderer(mime string) (RenderFactory, bool) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	factory, ok := renderers[mime]
+	return factory, ok
+}
+
+// acceptRange Accept 请求头中的一项
+type acceptRange struct {
+	typ     string  // 主类型, 例如 application 或 *
+	subtype string  // 子类型, 例如 json 或 *
+	q       float64 // 权重
+}
+
+// parseAccept 解析 Accept 请求头
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		mediaRange := strings.ToLower(strings.TrimSpace(params[0]))
+		if mediaRange == "" {
+			continue
+		}
+		typ, subtype := mediaRange, "*"
+		if i := strings.IndexByte(mediaRange, '/'); i >= 0 {
+			typ, subtype = mediaRange[:i], mediaRange[i+1:]
+		}
+
+		q := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if len(param) < 2 || param[0] != 'q' && param[0] != 'Q' || param[1] != '=' {
+				continue
+			}
+			if v, err := strconv.ParseFloat(param[2:], 64); err == nil && v >= 0 && v <= 1 {
+				q = v
+			} else {
+				q = 0
+			}
+		}
+		ranges = append(ranges, acceptRange{typ: typ, subtype: subtype, q: q})
+	}
+	return ranges
+}
+
+// quality 返回 mime 的权重, 使用最精确匹配的一项, 未匹配时返回 0
+func quality(ranges []acceptRange, mime string) float64 {
+	typ, subtype := mime, ""
+	if i := strings.IndexByte(mime, '/'); i >= 0 {
+		typ, subtype = mime[:i], mime[i+1:]
+	}
+
+	q, specificity := 0.0, -1
+	for _, r := range ranges {
+		s := -1
+		switch {
+		case r.typ == typ && r.subtype == subtype:
+			s = 2
+		case r.typ == typ && r.subtype == "*":
+			s = 1
+		case r.typ == "*" && r.subtype == "*":
+			s = 0
+		}
+		if s > specificity {
+			q, specificity = r.q, s
+		}
+	}
+	return q
+}
+
+// NegotiateFormat 根据 Accept 请求头从 offered 中选择权重最高的 MIME 类型
+// 权重相同时选择 offered 中靠前的一项, 均不可接受时返回空字符串, Accept 为空时返回第一项
+func (c *Context) NegotiateFormat(offered ...string) string {
+	if len(offered) == 0 {
+		return ""
+	}
+	header := c.Req.Header.Get("Accept")
+	if strings.TrimSpace(header) == "" {
+		return offered[0]
+	}
+
+	ranges := parseAccept(header)
+	best, bestQ := "", 0.0
+	for _, offer := range offered {
+		mime := offer
+		if i := strings.IndexByte(mime, ';'); i >= 0 {
+			mime = mime[:i]
+		}
+		mime = strings.ToLower(strings.TrimSpace(mime))
+		if q := quality(ranges, mime); q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// Negotiate 根据 Accept 请求头选择渲染器写入响应
+// 仅考虑已注册渲染器的 MIME 类型以及 text/html, 均不可接受时返回 406 并中止后续处理
+func (c *Context) Negotiate(code int, n Negotiate) {
+	offered := make([]string, 0, len(n.Offered))
+	for _, mime := range n.Offered {
+		mime = strings.ToLower(mime)
+		if _, ok := lookupRenderer(mime); ok || mime == MIMEHTML {
+			offered = append(offered, mime)
+		}
+	}
+
+	switch format := c.NegotiateFormat(offered...); format {
+	case "":
+		c.Abort()
+		if c.problemDetails() {
+			c.Problem(NewProblem(http.StatusNotAcceptable, ""))
+			return
+		}
+		c.String(http.StatusNotAcceptable, NotAcceptableError.Error())
+	case MIMEHTML:
+		c.HTML(code, n.HTMLName, n.data(format))
+	default:
+		factory, _ := lookupRenderer(format)
+		c.Render(code, factory(n.data(format)))
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContext_NegotiateFormat(t *testing.T) {
+	tests := []struct {
+		accept  string
+		offered []string
+		want    string
+	}{
+		{"", []string{MIMEJSON, MIMEXML}, MIMEJSON},
+		{"application/xml", []string{MIMEJSON, MIMEXML}, MIMEXML},
+		{"application/xml;q=0.5, application/json;q=0.9", []string{MIMEXML, MIMEJSON}, MIMEJSON},
+		{"text/*, application/json;q=0.1", []string{MIMEJSON, MIMEHTML}, MIMEHTML},
+		{"*/*;q=0.1, application/xml", []string{MIMEJSON, MIMEXML}, MIMEXML},
+		{"*/*", []string{MIMEJSON, MIMEXML}, MIMEJSON},
+		{"application/*;q=0.8, application/json;q=0", []string{MIMEJSON, MIMEYAML}, MIMEYAML},
+		{"text/html", []string{MIMEJSON, MIMEXML}, ""},
+		{"application/json;q=abc", []string{MIMEJSON}, ""},
+	}
+	for _, tt := range tests {
+		c := &Context{}
+		c.reset(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+		c.Req.Header.Set("Accept", tt.accept)
+		if got := c.NegotiateFormat(tt.offered...); got != tt.want {
+			t.Errorf("NegotiateFormat(%q, %v) = %q, want %q", tt.accept, tt.offered, got, tt.want)
+		}
+	}
+}
+
+type negotiateXML struct {
+	A int
+}
+
+type csvRender struct {
+	data interface{}
+}
+
+func (r csvRender) ContentType() string { return "text/csv" }
+
+func (r csvRender) Render(w io.Writer) error {
+	_, err := io.WriteString(w, "a,b")
+	return err
+}
+
+func TestContext_Negotiate(t *testing.T) {
+	RegisterRenderer("text/csv", func(data interface{}) Render { return csvRender{data} })
+	defer func() {
+		renderersMu.Lock()
+		delete(renderers, "text/csv")
+		renderersMu.Unlock()
+	}()
+
+	engine := New()
+	engine.GET("/", func(c *Context) {
+		c.Negotiate(http.StatusOK, Negotiate{
+			Offered:  []string{MIMEJSON, MIMEXML, "text/csv", "application/unknown"},
+			Data:     Z{"a": 1},
+			XMLData:  negotiateXML{1},
+			HTMLName: "index",
+		})
+	})
+
+	tests := []struct {
+		accept      string
+		code        int
+		contentType string
+		body        string
+	}{
+		{"application/json", 200, "application/json; charset=utf-8", "{\"a\":1}\n"},
+		{"application/xml", 200, "application/xml; charset=utf-8", "<negotiateXML><A>1</A></negotiateXML>"},
+		{"text/csv", 200, "text/csv", "a,b"},
+		{"application/unknown", 406, "text/plain; charset=utf-8", NotAcceptableError.Error()},
+		{"text/html", 406, "text/plain; charset=utf-8", NotAcceptableError.Error()},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept", tt.accept)
+		engine.ServeHTTP(w, req)
+		if w.Code != tt.code || w.Header().Get("Content-Type") != tt.contentType || w.Body.String() != tt.body {
+			t.Errorf("Accept %q: got %d %q %q", tt.accept, w.Code, w.Header().Get("Content-Type"), w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,122 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// MIMEProblemJSON RFC 7807 问题详情的 MIME 类型
+const MIMEProblemJSON = "application/problem+json"
+
+// Problem RFC 7807 问题详情
+type Problem struct {
+	Type       string                 // 问题类型的 URI, 为空时视为 about:blank
+	Title      string                 // 问题类型的简短描述
+	Status     int                    // HTTP 状态码
+	Detail     string                 // 本次问题的具体描述
+	Instance   string                 // 发生问题的资源 URI
+	Extensions map[string]interface{} // 扩展成员, 与标准成员重名时忽略
+}
+
+// NewProblem 创建 about:blank 类型的问题详情, 标题为状态码的描述
+func NewProblem(status int, detail string) Problem {
+	return Problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: detail}
+}
+
+// MarshalJSON 将扩展成员与标准成员输出在同一层级
+func (p Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]interface{}, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		members[k] = v
+	}
+	typ := p.Type
+	if typ == "" {
+		typ = "about:blank"
+	}
+	members["type"] = typ
+	members["title"] = p.Title
+	if p.Status != 0 {
+		members["status"] = p.Status
+	} else {
+		delete(members, "status")
+	}
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	} else {
+		delete(members, "detail")
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	} else {
+		delete(members, "instance")
+	}
+	return json.Marshal(members)
+}
+
+// RenderProblem application/problem+json 渲染器
+type RenderProblem struct {
+	Problem Problem
+}
+
+func (r RenderProblem) ContentType() string { return MIMEProblemJSON + "; charset=utf-8" }
+
+func (r RenderProblem) Render(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r.Problem)
+}
+
+// Problem 写入问题详情响应, 状态码取自 p.Status, Instance 为空时使用请求路径
+func (c *Context) Problem(p Problem) {
+	if p.Status == 0 {
+		p.Status = http.StatusInternalServerError
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Instance == "" && c.Req != nil {
+		p.Instance = c.Req.URL.RequestURI()
+	}
+	c.Render(p.Status, RenderProblem{Problem: p})
+}
+
+// problemDetails 是否以问题详情输出框架生成的错误响应
+func (c *Context) problemDetails() bool {
+	return c.engine != nil && c.engine.ProblemDetails
+}
+
+// writeError 中止处理链并输出错误, extensions 为附加字段
+// 开启 Engine.ProblemDetails 时输出问题详情, 否则输出 {"message": message, ...}
+func (c *Context) writeError(code int, message string, extensions Z) {
+	c.Abort()
+	if c.problemDetails() {
+		p := NewProblem(code, message)
+		if message == p.Title {
+			p.Detail = ""
+		}
+		p.Extensions = extensions
+		c.Problem(p)
+		return
+	}
+	body := Z{"message": message}
+	for k, v := range extensions {
+		body[k] = v
+	}
+	c.JSON(code, body)
+}
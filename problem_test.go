@@ -0,0 +1,114 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProblem_MarshalJSON(t *testing.T) {
+	p := Problem{
+		Type:       "https://example.com/probs/out-of-credit",
+		Title:      "You do not have enough credit.",
+		Status:     http.StatusForbidden,
+		Detail:     "Your current balance is 30, but that costs 50.",
+		Instance:   "/account/12345/msgs/abc",
+		Extensions: map[string]interface{}{"balance": 30, "title": "ignored"},
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"balance":30,"detail":"Your current balance is 30, but that costs 50.","instance":"/account/12345/msgs/abc",` +
+		`"status":403,"title":"You do not have enough credit.","type":"https://example.com/probs/out-of-credit"}`
+	if string(data) != want {
+		t.Fatalf("got %s", data)
+	}
+
+	data, _ = json.Marshal(Problem{Title: "Not Found", Extensions: map[string]interface{}{"status": 1}})
+	if string(data) != `{"title":"Not Found","type":"about:blank"}` {
+		t.Fatalf("got %s", data)
+	}
+}
+
+func TestEngine_ProblemDetails(t *testing.T) {
+	engine := New()
+	engine.ProblemDetails = true
+	engine.Use(Recovery())
+	engine.GET("/panic", func(c *Context) {
+		panic("boom")
+	})
+	engine.POST("/users", func(c *Context) {
+		var u struct {
+			Name string `json:"name" binding:"required"`
+		}
+		c.BindJSON(&u)
+	})
+	engine.GET("/error", func(c *Context) {
+		c.Error(NewHTTPError(http.StatusConflict, "user exists").WithCode("conflict"))
+	})
+	engine.GET("/internal", func(c *Context) {
+		c.Error(errors.New("secret"))
+	})
+	engine.GET("/render", func(c *Context) {
+		c.JSON(http.StatusOK, math.NaN())
+	})
+	engine.GET("/render-problem", func(c *Context) {
+		c.Problem(Problem{Status: http.StatusBadRequest, Extensions: map[string]interface{}{"bad": math.Inf(1)}})
+	})
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		code   int
+		want   string
+	}{
+		{"GET", "/missing?a=1", "", 404, `{"instance":"/missing?a=1","status":404,"title":"Not Found","type":"about:blank"}`},
+		{"DELETE", "/panic", "", 405, `{"instance":"/panic","status":405,"title":"Method Not Allowed","type":"about:blank"}`},
+		{"GET", "/panic", "", 500, `{"instance":"/panic","status":500,"title":"Internal Server Error","type":"about:blank"}`},
+		{"POST", "/users", "{}", 400, `{"detail":"validation failed","errors":[{"field":"Name","tag":"required","message":"Name is required"}],` +
+			`"instance":"/users","status":400,"title":"Bad Request","type":"about:blank"}`},
+		{"GET", "/error", "", 409, `{"code":"conflict","detail":"user exists","instance":"/error","status":409,"title":"Conflict","type":"about:blank"}`},
+		{"GET", "/internal", "", 500, `{"instance":"/internal","status":500,"title":"Internal Server Error","type":"about:blank"}`},
+		{"GET", "/render", "", 500, `{"instance":"/render","status":500,"title":"Internal Server Error","type":"about:blank"}`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+		req.Header.Set("Content-Type", "application/json")
+		engine.ServeHTTP(w, req)
+		if w.Code != tt.code || w.Header().Get("Content-Type") != "application/problem+json; charset=utf-8" ||
+			strings.TrimSpace(w.Body.String()) != tt.want {
+			t.Errorf("%s %s: got %d %q %s", tt.method, tt.path, w.Code, w.Header().Get("Content-Type"), w.Body.String())
+		}
+	}
+
+	// 问题详情本身无法编码时回退为文本
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/render-problem", nil))
+	if w.Code != 500 || w.Body.String() != "Internal Server Error" {
+		t.Fatalf("render-problem: got %d %q", w.Code, w.Body.String())
+	}
+}
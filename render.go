@@ -0,0 +1,360 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Render 响应渲染器
+// 内容先写入缓冲区, 渲染成功后才写入状态码与响应体, 因此渲染失败时可以返回完整的 500 响应
+type Render interface {
+	// ContentType 响应的 Content-Type, 为空时不设置
+	ContentType() string
+	// Render 将响应体写入 w
+	Render(w io.Writer) error
+}
+
+// defaultSecureJSONPrefix SecureJSON 默认的前缀
+const defaultSecureJSONPrefix = "while(1);"
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+// RenderJSON JSON 渲染器
+type RenderJSON struct {
+	Data interface{}
+}
+
+func (r RenderJSON) ContentType() string { return "application/json; charset=utf-8" }
+
+func (r RenderJSON) Render(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r.Data)
+}
+
+// RenderIndentedJSON 带缩进的 JSON 渲染器
+type RenderIndentedJSON struct {
+	Data interface{}
+}
+
+func (r RenderIndentedJSON) ContentType() string { return "application/json; charset=utf-8" }
+
+func (r RenderIndentedJSON) Render(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(r.Data)
+}
+
+// RenderPureJSON 不转义 HTML 字符的 JSON 渲染器
+type RenderPureJSON struct {
+	Data interface{}
+}
+
+func (r RenderPureJSON) ContentType() string { return "application/json; charset=utf-8" }
+
+func (r RenderPureJSON) Render(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(r.Data)
+}
+
+// RenderSecureJSON 防止 JSON 劫持的渲染器, 内容为数组时添加 Prefix 前缀
+type RenderSecureJSON struct {
+	Prefix string
+	Data   interface{}
+}
+
+func (r RenderSecureJSON) ContentType() string { return "application/json; charset=utf-8" }
+
+func (r RenderSecureJSON) Render(w io.Writer) error {
+	data, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	if bytes.HasPrefix(data, []byte("[")) && bytes.HasSuffix(data, []byte("]")) {
+		if _, err := io.WriteString(w, r.Prefix); err != nil {
+			return err
+		}
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// RenderJSONP JSONP 渲染器, Callback 为空时等同于 RenderJSON
+type RenderJSONP struct {
+	Callback string
+	Data     interface{}
+}
+
+func (r RenderJSONP) ContentType() string { return "application/javascript; charset=utf-8" }
+
+func (r RenderJSONP) Render(w io.Writer) error {
+	data, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	if r.Callback == "" {
+		_, err = w.Write(data)
+		return err
+	}
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer putBuffer(buf)
+	buf.WriteString(template.JSEscapeString(r.Callback))
+	buf.WriteByte('(')
+	buf.Write(data)
+	buf.WriteString(");")
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// RenderAsciiJSON 仅包含 ASCII 字符的 JSON 渲染器, 非 ASCII 字符转义为 \uXXXX
+type RenderAsciiJSON struct {
+	Data interface{}
+}
+
+func (r RenderAsciiJSON) ContentType() string { return "application/json" }
+
+func (r RenderAsciiJSON) Render(w io.Writer) error {
+	data, err := json.Marshal(r.Data)
+	if err != nil {
+		return err
+	}
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer putBuffer(buf)
+	for len(data) > 0 {
+		rn, size := utf8.DecodeRune(data)
+		if rn < utf8.RuneSelf {
+			buf.WriteByte(data[0])
+		} else if rn > 0xFFFF {
+			// 超出基本多文种平面的字符使用代理对表示
+			rn -= 0x10000
+			fmt.Fprintf(buf, `\u%04x\u%04x`, 0xD800+(rn>>10), 0xDC00+(rn&0x3FF))
+		} else {
+			fmt.Fprintf(buf, `\u%04x`, rn)
+		}
+		data = data[size:]
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// RenderXML XML 渲染器
+type RenderXML struct {
+	Data interface{}
+}
+
+func (r RenderXML) ContentType() string { return "application/xml; charset=utf-8" }
+
+func (r RenderXML) Render(w io.Writer) error {
+	return xml.NewEncoder(w).Encode(r.Data)
+}
+
+// RenderYAML YAML 渲染器
+type RenderYAML struct {
+	Data interface{}
+}
+
+func (r RenderYAML) ContentType() string { return "application/x-yaml; charset=utf-8" }
+
+func (r RenderYAML) Render(w io.Writer) error {
+	return encodeYAML(w, r.Data)
+}
+
+// RenderTOML TOML 渲染器, Data 必须为结构体或映射
+type RenderTOML struct {
+	Data interface{}
+}
+
+func (r RenderTOML) ContentType() string { return "application/toml; charset=utf-8" }
+
+func (r RenderTOML) Render(w io.Writer) error {
+	return encodeTOML(w, r.Data)
+}
+
+// RenderString 文本渲染器
+type RenderString struct {
+	Format string
+	Values []interface{}
+}
+
+func (r RenderString) ContentType() string { return "text/plain; charset=utf-8" }
+
+func (r RenderString) Render(w io.Writer) error {
+	// 不含 % 时无需格式化, 含 % 时即使没有参数也要处理 %% 等转义
+	if len(r.Values) == 0 && !strings.Contains(r.Format, "%") {
+		_, err := io.WriteString(w, r.Format)
+		return err
+	}
+	_, err := fmt.Fprintf(w, r.Format, r.Values...)
+	return err
+}
+
+// RenderData 二进制数据渲染器
+type RenderData struct {
+	ContentTypeValue string // 为空时由 net/http 根据内容推断
+	Data             []byte
+}
+
+func (r RenderData) ContentType() string { return r.ContentTypeValue }
+
+func (r RenderData) Render(w io.Writer) error {
+	_, err := w.Write(r.Data)
+	return err
+}
+
+// RenderHTML HTML 模板渲染器
+type RenderHTML struct {
+	Template *template.Template
+	Name     string
+	Data     interface{}
+}
+
+func (r RenderHTML) ContentType() string { return "text/html; charset=utf-8" }
+
+func (r RenderHTML) Render(w io.Writer) error {
+	if r.Template == nil {
+		return errors.New("zero: html templates are not loaded, call LoadHTMLGlob first")
+	}
+	return r.Template.ExecuteTemplate(w, r.Name, r.Data)
+}
+
+// putBuffer 归还缓冲区, 过大的缓冲区直接丢弃
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > 64<<10 {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
+
+// bodyAllowedForStatus 状态码是否允许包含响应体
+func bodyAllowedForStatus(status int) bool {
+	switch {
+	case status >= 100 && status <= 199:
+		return false
+	case status == http.StatusNoContent, status == http.StatusNotModified:
+		return false
+	}
+	return true
+}
+
+/****************************
+ * YAML 与 TOML 编码共用的反射工具
+ ****************************/
+
+// encodeField 待编码的字段或映射项
+type encodeField struct {
+	name  string
+	value reflect.Value
+}
+
+// structFields 按 tag 标签返回结构体中待编码的字段
+// 标签格式为 `yaml:"name,omitempty"`, 标签为 - 时忽略该字段, 未设置名称的匿名结构体字段会被展开
+// 未设置名称时使用字段名, lower 为 true 时转换为小写
+func structFields(v reflect.Value, tag string, lower bool) []encodeField {
+	var fields []encodeField
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" && !sf.Anonymous {
+			continue
+		}
+		name, opts := sf.Tag.Get(tag), ""
+		if name == "-" {
+			continue
+		}
+		if j := strings.IndexByte(name, ','); j >= 0 {
+			name, opts = name[:j], name[j+1:]
+		}
+
+		fv := v.Field(i)
+		if sf.Anonymous && name == "" {
+			ev := indirect(fv)
+			if ev.Kind() == reflect.Struct {
+				fields = append(fields, structFields(ev, tag, lower)...)
+				continue
+			}
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+			if lower {
+				name = strings.ToLower(name)
+			}
+		}
+		if isZeroValue(fv) && containsString(strings.Split(opts, ","), "omitempty") {
+			continue
+		}
+		fields = append(fields, encodeField{name: name, value: fv})
+	}
+	return fields
+}
+
+// mapFields 返回按键排序的映射项
+func mapFields(v reflect.Value) []encodeField {
+	fields := make([]encodeField, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		fields = append(fields, encodeField{name: fmt.Sprint(iter.Key().Interface()), value: iter.Value()})
+	}
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].name < fields[j].name
+	})
+	return fields
+}
+
+// indirect 解引用指针与接口, 为 nil 时返回无效值
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// marshalText 值实现了 encoding.TextMarshaler 时返回其文本形式, 例如 time.Time
+func marshalText(v reflect.Value) (string, bool, error) {
+	if !v.IsValid() || !v.CanInterface() {
+		return "", false, nil
+	}
+	m, ok := v.Interface().(interface{ MarshalText() ([]byte, error) })
+	if !ok {
+		return "", false, nil
+	}
+	text, err := m.MarshalText()
+	return string(text), true, err
+}
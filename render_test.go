@@ -0,0 +1,252 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"bytes"
+	"encoding/base64"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func performRender(handler HandlerFunc, target string) *httptest.ResponseRecorder {
+	engine := New()
+	engine.GET("/", handler)
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+	return w
+}
+
+func TestContext_Render_json(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		handler     HandlerFunc
+		contentType string
+		body        string
+	}{
+		{"json", "/", func(c *Context) { c.JSON(200, Z{"html": "<b>"}) },
+			"application/json; charset=utf-8", "{\"html\":\"\\u003cb\\u003e\"}\n"},
+		{"pure", "/", func(c *Context) { c.PureJSON(200, Z{"html": "<b>"}) },
+			"application/json; charset=utf-8", "{\"html\":\"<b>\"}\n"},
+		{"indented", "/", func(c *Context) { c.IndentedJSON(200, Z{"a": 1}) },
+			"application/json; charset=utf-8", "{\n    \"a\": 1\n}\n"},
+		{"secure array", "/", func(c *Context) { c.SecureJSON(200, []int{1, 2}) },
+			"application/json; charset=utf-8", "while(1);[1,2]"},
+		{"secure object", "/", func(c *Context) { c.SecureJSON(200, Z{"a": 1}) },
+			"application/json; charset=utf-8", `{"a":1}`},
+		{"jsonp", "/?callback=cb", func(c *Context) { c.JSONP(200, Z{"a": 1}) },
+			"application/javascript; charset=utf-8", `cb({"a":1});`},
+		{"jsonp without callback", "/", func(c *Context) { c.JSONP(200, Z{"a": 1}) },
+			"application/json; charset=utf-8", "{\"a\":1}\n"},
+		{"ascii", "/", func(c *Context) { c.AsciiJSON(200, Z{"name": "陈😀"}) },
+			"application/json", `{"name":"\u9648\ud83d\ude00"}`},
+		{"xml", "/", func(c *Context) {
+			c.XML(200, struct {
+				XMLName struct{} `xml:"user"`
+				Name    string   `xml:"name"`
+			}{Name: "chenquan"})
+		}, "application/xml; charset=utf-8", "<user><name>chenquan</name></user>"},
+		{"string", "/", func(c *Context) { c.String(200, "100%%") },
+			"text/plain; charset=utf-8", "100%"},
+		{"data", "/", func(c *Context) { c.DataWithType(200, "image/png", []byte("png")) },
+			"image/png", "png"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := performRender(tt.handler, tt.target)
+			if w.Code != 200 || w.Header().Get("Content-Type") != tt.contentType || w.Body.String() != tt.body {
+				t.Fatalf("got %d %q %q", w.Code, w.Header().Get("Content-Type"), w.Body.String())
+			}
+		})
+	}
+}
+
+func TestContext_Render_error(t *testing.T) {
+	w := performRender(func(c *Context) {
+		c.JSON(200, Z{"f": math.Inf(1)})
+	}, "/")
+	if w.Code != http.StatusInternalServerError || w.Body.String() != "Internal Server Error" {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+
+	w = performRender(func(c *Context) {
+		c.HTML(200, "index", nil)
+	}, "/")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("HTML without templates: got %d", w.Code)
+	}
+
+	w = performRender(func(c *Context) {
+		c.JSON(http.StatusNoContent, Z{"a": 1})
+	}, "/")
+	if w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Fatalf("204: got %d %q", w.Code, w.Body.String())
+	}
+}
+
+type renderServer struct {
+	Host string
+	Port int
+}
+
+type renderConfig struct {
+	Name     string            `yaml:"name" toml:"name"`
+	Debug    bool              `yaml:"debug" toml:"debug"`
+	Ratio    float64           `yaml:"ratio" toml:"ratio"`
+	Version  string            `yaml:"version" toml:"version"`
+	Tags     []string          `yaml:"tags" toml:"tags"`
+	Labels   map[string]string `yaml:"labels" toml:"labels"`
+	Created  time.Time         `yaml:"created" toml:"created"`
+	Empty    string            `yaml:"empty,omitempty" toml:"empty,omitempty"`
+	Server   renderServer      `yaml:"server" toml:"server"`
+	Replicas []renderServer    `yaml:"replicas" toml:"replicas"`
+	Matrix   [][]int           `yaml:"matrix" toml:"matrix"`
+}
+
+var testRenderConfig = renderConfig{
+	Name:     "zero: router",
+	Debug:    true,
+	Ratio:    2,
+	Version:  "1.0",
+	Tags:     []string{"a", "true"},
+	Labels:   map[string]string{"b": "2", "a": "1"},
+	Created:  time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+	Server:   renderServer{Host: "localhost", Port: 8080},
+	Replicas: []renderServer{{Host: "r1", Port: 1}, {Host: "r2", Port: 2}},
+	Matrix:   [][]int{{1, 2}, {3}},
+}
+
+func TestContext_YAML(t *testing.T) {
+	w := performRender(func(c *Context) { c.YAML(200, testRenderConfig) }, "/")
+	want := `name: "zero: router"
+debug: true
+ratio: 2
+version: "1.0"
+tags:
+- a
+- "true"
+labels:
+  a: "1"
+  b: "2"
+created: 2020-06-01T00:00:00Z
+server:
+  host: localhost
+  port: 8080
+replicas:
+- host: r1
+  port: 1
+- host: r2
+  port: 2
+matrix:
+- - 1
+  - 2
+- - 3
+`
+	if w.Header().Get("Content-Type") != "application/x-yaml; charset=utf-8" || w.Body.String() != want {
+		t.Fatalf("got %q\n%s", w.Header().Get("Content-Type"), w.Body.String())
+	}
+}
+
+func TestContext_TOML(t *testing.T) {
+	w := performRender(func(c *Context) { c.TOML(200, testRenderConfig) }, "/")
+	want := `name = "zero: router"
+debug = true
+ratio = 2.0
+version = "1.0"
+tags = ["a", "true"]
+created = 2020-06-01T00:00:00Z
+matrix = [[1, 2], [3]]
+
+[labels]
+a = "1"
+b = "2"
+
+[server]
+Host = "localhost"
+Port = 8080
+
+[[replicas]]
+Host = "r1"
+Port = 1
+
+[[replicas]]
+Host = "r2"
+Port = 2
+`
+	if w.Header().Get("Content-Type") != "application/toml; charset=utf-8" || w.Body.String() != want {
+		t.Fatalf("got %q\n%s", w.Header().Get("Content-Type"), w.Body.String())
+	}
+
+	w = performRender(func(c *Context) { c.TOML(200, []int{1}) }, "/")
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("non-table TOML: got %d", w.Code)
+	}
+}
+
+func TestContext_YAML_TOML_scalars(t *testing.T) {
+	data := []byte("zero\x00\xff")
+	value := Z{
+		"date":    "2001-12-14",
+		"stamp":   "2001-12-14 21:59:43",
+		"month":   "2001-12",
+		"data":    data,
+		"empty":   []byte{},
+		"chunks":  [][]byte{{1}},
+		"percent": "100%",
+	}
+
+	w := performRender(func(c *Context) { c.YAML(200, value) }, "/")
+	want := `chunks:
+- AQ==
+data: emVybwD/
+date: "2001-12-14"
+empty: ""
+month: 2001-12
+percent: 100%
+stamp: "2001-12-14 21:59:43"
+`
+	if w.Body.String() != want {
+		t.Fatalf("yaml:\n%s", w.Body.String())
+	}
+
+	w = performRender(func(c *Context) { c.TOML(200, value) }, "/")
+	want = `chunks = ["AQ=="]
+data = "emVybwD/"
+date = "2001-12-14"
+empty = ""
+month = "2001-12"
+percent = "100%"
+stamp = "2001-12-14 21:59:43"
+`
+	if w.Body.String() != want {
+		t.Fatalf("toml:\n%s", w.Body.String())
+	}
+
+	// 从输出中取回的字节与原值一致
+	line := strings.SplitN(w.Body.String(), "\n", 3)[1]
+	encoded, _ := strconv.Unquote(strings.TrimPrefix(line, "data = "))
+	if got, err := base64.StdEncoding.DecodeString(encoded); err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("base64 round trip: %q, %v", got, err)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"bufio"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+)
+
+// noWritten 尚未写入响应头时的 size
+const noWritten = -1
+
+// ResponseWriter 记录状态码、响应体大小以及响应头是否已写入的 http.ResponseWriter
+// 状态码在首次写入响应体或调用 WriteHeaderNow 时才真正写入, 因此写入前可以多次修改,
+// 写入后再次修改会被忽略, 避免 net/http 的 superfluous WriteHeader 警告
+type ResponseWriter interface {
+	http.ResponseWriter
+	http.Hijacker
+	http.Flusher
+	http.CloseNotifier
+	http.Pusher
+
+	// Status 返回响应状态码, 未设置时为 200
+	Status() int
+	// Size 返回已写入的响应体字节数, 未写入响应头时为 -1
+	Size() int
+	// Written 响应头是否已写入
+	Written() bool
+	// WriteHeaderNow 立即写入响应头
+	WriteHeaderNow()
+	// Unwrap 返回原始的 http.ResponseWriter, 用于 http.ResponseController
+	Unwrap() http.ResponseWriter
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	size   int
+	status int
+}
+
+var _ ResponseWriter = (*responseWriter)(nil)
+
+// reset 使用新的 http.ResponseWriter 重置, 用于上下文复用
+func (w *responseWriter) reset(writer http.ResponseWriter) {
+	w.ResponseWriter = writer
+	w.size = noWritten
+	w.status = http.StatusOK
+}
+
+// WriteHeader 记录状态码, 响应头已写入时忽略
+func (w *responseWriter) WriteHeader(code int) {
+	if code > 0 && w.status != code {
+		if w.Written() {
+			log.Printf("[ZERO] [WARNING] headers were already written, wanted to override status code %d with %d", w.status, code)
+			return
+		}
+		w.status = code
+	}
+}
+
+func (w *responseWriter) WriteHeaderNow() {
+	if !w.Written() {
+		w.size = 0
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *responseWriter) Write(data []byte) (n int, err error) {
+	w.WriteHeaderNow()
+	n, err = w.ResponseWriter.Write(data)
+	w.size += n
+	return
+}
+
+func (w *responseWriter) Status() int {
+	return w.status
+}
+
+func (w *responseWriter) Size() int {
+	return w.size
+}
+
+func (w *responseWriter) Written() bool {
+	return w.size != noWritten
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Hijack 接管连接, 接管后不再写入响应头
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("zero: the ResponseWriter does not implement http.Hijacker")
+	}
+	if w.size < 0 {
+		w.size = 0
+	}
+	return hijacker.Hijack()
+}
+
+// CloseNotify 实现 http.CloseNotifier, 原始 ResponseWriter 不支持时返回永不关闭的通道
+func (w *responseWriter) CloseNotify() <-chan bool {
+	if notifier, ok := w.ResponseWriter.(http.CloseNotifier); ok {
+		return notifier.CloseNotify()
+	}
+	return make(chan bool)
+}
+
+// Flush 写入响应头并刷新缓冲区
+func (w *responseWriter) Flush() {
+	w.WriteHeaderNow()
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Push 实现 HTTP/2 服务端推送, 原始 ResponseWriter 不支持时返回 http.ErrNotSupported
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
@@ -0,0 +1,116 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResponseWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &responseWriter{}
+	w.reset(rec)
+	if w.Written() || w.Size() != -1 || w.Status() != http.StatusOK {
+		t.Fatalf("initial state: written=%v size=%d status=%d", w.Written(), w.Size(), w.Status())
+	}
+
+	// 写入前可以多次修改状态码
+	w.WriteHeader(http.StatusCreated)
+	w.WriteHeader(http.StatusAccepted)
+	if w.Written() || rec.Code != http.StatusOK || rec.Flushed {
+		t.Fatal("WriteHeader should be deferred")
+	}
+
+	w.Write([]byte("hello"))
+	w.WriteHeader(http.StatusInternalServerError)
+	if !w.Written() || w.Size() != 5 || w.Status() != http.StatusAccepted || rec.Code != http.StatusAccepted {
+		t.Fatalf("written=%v size=%d status=%d recorder=%d", w.Written(), w.Size(), w.Status(), rec.Code)
+	}
+
+	w.Flush()
+	if !rec.Flushed {
+		t.Fatal("Flush should reach the underlying writer")
+	}
+	if _, _, err := w.Hijack(); err == nil {
+		t.Fatal("Hijack should fail on a recorder")
+	}
+	if err := w.Push("/app.js", nil); err != http.ErrNotSupported {
+		t.Fatalf("Push error = %v", err)
+	}
+	if w.Unwrap() != rec {
+		t.Fatal("Unwrap should return the underlying writer")
+	}
+}
+
+func TestContext_ResponseStatus(t *testing.T) {
+	engine := New()
+	var status, size int
+	engine.Use(func(c *Context) {
+		c.Next()
+		status, size = c.Res.Status(), c.Res.Size()
+	})
+	engine.GET("/raw", func(c *Context) {
+		c.Res.WriteHeader(http.StatusCreated)
+		c.Res.Write([]byte("raw"))
+	})
+	engine.GET("/empty", func(c *Context) {
+		c.Status(http.StatusNoContent)
+	})
+	engine.GET("/twice", func(c *Context) {
+		c.String(http.StatusOK, "ok")
+		c.JSON(http.StatusInternalServerError, Z{"a": 1})
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		size   int
+		code   int
+	}{
+		{"/raw", http.StatusCreated, 3, http.StatusCreated},
+		{"/empty", http.StatusNoContent, -1, http.StatusNoContent},
+		{"/twice", http.StatusOK, 2 + 8, http.StatusOK},
+		{"/missing", http.StatusNotFound, len(NotFoundError.Error()), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+		if status != tt.status || size != tt.size || w.Code != tt.code {
+			t.Errorf("%s: status=%d size=%d code=%d", tt.path, status, size, w.Code)
+		}
+	}
+}
+
+func TestContext_Copy_responseWriter(t *testing.T) {
+	engine := New()
+	var cp *Context
+	engine.GET("/first", func(c *Context) {
+		c.Status(http.StatusAccepted)
+		cp = c.Copy()
+	})
+	engine.GET("/second", func(c *Context) {
+		c.Status(http.StatusCreated)
+	})
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/first", nil))
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/second", nil))
+	if cp.Res.Status() != http.StatusAccepted || cp.Res.Written() {
+		t.Fatalf("copied writer changed with the next request: status=%d written=%v", cp.Res.Status(), cp.Res.Written())
+	}
+}
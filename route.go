@@ -0,0 +1,282 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+)
+
+// Route 已注册的路由
+type Route struct {
+	Host        string        // 域名, 为空时表示默认路由
+	Method      string        // 请求方式
+	Pattern     string        // 完整路由, 包含分组前缀
+	name        string        // 路由名称
+	middlewares []HandlerFunc // 注册时所在分组及其祖先分组的中间件
+	metaMu      sync.Mutex    // 保护元数据的写入
+	meta        atomic.Value  // *RouteMeta, 写时复制, 读取时无需加锁
+	handlers    []HandlerFunc // 处理函数
+	engine      *Engine
+	failed      bool // 注册失败, 未添加到路由表
+}
+
+// RouteMeta 路由元数据, 可在中间件中通过 Context.Metadata 读取
+type RouteMeta struct {
+	Description string                 // 路由描述
+	Tags        []string               // 标签
+	Permissions []string               // 访问该路由需要的权限
+	RateLimit   string                 // 限流等级
+	Deprecated  bool                   // 是否已废弃
+	Values      map[string]interface{} // 自定义元数据
+}
+
+// HasTag 是否包含指定标签
+func (m RouteMeta) HasTag(tag string) bool {
+	return containsString(m.Tags, tag)
+}
+
+// HasPermission 是否需要指定权限
+func (m RouteMeta) HasPermission(permission string) bool {
+	return containsString(m.Permissions, permission)
+}
+
+// Value 返回自定义元数据
+func (m RouteMeta) Value(key string) (value interface{}, ok bool) {
+	value, ok = m.Values[key]
+	return
+}
+
+func containsString(ss []string, s string) bool {
+	for _, item := range ss {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// RouteInfo 路由信息
+type RouteInfo struct {
+	Host        string   // 域名, 为空时表示默认路由
+	Method      string   // 请求方式
+	Path        string   // 完整路由
+	Name        string   // 路由名称
+	Handlers    []string // 处理函数名称
+	Middlewares int      // 作用于该路由的分组中间件数量
+	Meta        RouteMeta
+}
+
+// RoutesInfo 路由表
+type RoutesInfo []RouteInfo
+
+// String 以表格形式输出路由表
+func (ri RoutesInfo) String() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HOST\tMETHOD\tPATH\tNAME\tMIDDLEWARES\tHANDLERS")
+	for _, info := range ri {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%s\n", info.Host, info.Method, info.Path, info.Name, info.Middlewares, strings.Join(info.Handlers, ", "))
+	}
+	w.Flush()
+	return b.String()
+}
+
+// Routes 返回所有已注册路由的信息, 按域名、路径和请求方式排序
+func (e *Engine) Routes() RoutesInfo {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	routes := make(RoutesInfo, 0, len(e.routes))
+	for _, route := range e.routes {
+		routes = append(routes, RouteInfo{
+			Host:        route.Host,
+			Method:      route.Method,
+			Path:        route.Pattern,
+			Name:        route.name,
+			Handlers:    handlerNames(route.handlers),
+			Middlewares: len(route.middlewares),
+			Meta:        route.Metadata(),
+		})
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Host != routes[j].Host {
+			return routes[i].Host < routes[j].Host
+		}
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// Name 为路由命名, 命名后可通过 Engine.URL 反向生成路径
+// 同一名称只能用于一条路由, 注册失败的路由不会被命名
+func (r *Route) Name(name string) *Route {
+	if r.failed {
+		return r
+	}
+	e := r.engine
+	e.mu.Lock()
+	existing, ok := e.namedRoutes[name]
+	if !ok || existing == r {
+		r.name = name
+		e.namedRoutes[name] = r
+	}
+	e.mu.Unlock()
+
+	if ok && existing != r {
+		e.routeError(fmt.Errorf("route name %q is already used by %s %s", name, existing.Method, existing.Pattern))
+	}
+	return r
+}
+
+// Metadata 返回路由元数据
+func (r *Route) Metadata() RouteMeta {
+	if meta, ok := r.meta.Load().(*RouteMeta); ok {
+		return *meta
+	}
+	return RouteMeta{}
+}
+
+// updateMeta 复制元数据并修改后发布, 已开始处理请求的路由也可以安全地修改
+func (r *Route) updateMeta(update func(meta *RouteMeta)) *Route {
+	r.metaMu.Lock()
+	defer r.metaMu.Unlock()
+	meta := r.Metadata()
+	meta.Tags = append([]string(nil), meta.Tags...)
+	meta.Permissions = append([]string(nil), meta.Permissions...)
+	values := make(map[string]interface{}, len(meta.Values))
+	for k, v := range meta.Values {
+		values[k] = v
+	}
+	meta.Values = values
+	update(&meta)
+	r.meta.Store(&meta)
+	return r
+}
+
+// Describe 设置路由描述
+func (r *Route) Describe(description string) *Route {
+	return r.updateMeta(func(meta *RouteMeta) {
+		meta.Description = description
+	})
+}
+
+// Tag 添加标签
+func (r *Route) Tag(tags ...string) *Route {
+	return r.updateMeta(func(meta *RouteMeta) {
+		meta.Tags = append(meta.Tags, tags...)
+	})
+}
+
+// Permit 添加访问该路由需要的权限
+func (r *Route) Permit(permissions ...string) *Route {
+	return r.updateMeta(func(meta *RouteMeta) {
+		meta.Permissions = append(meta.Permissions, permissions...)
+	})
+}
+
+// RateLimit 设置限流等级
+func (r *Route) RateLimit(class string) *Route {
+	return r.updateMeta(func(meta *RouteMeta) {
+		meta.RateLimit = class
+	})
+}
+
+// Deprecate 标记路由已废弃
+func (r *Route) Deprecate() *Route {
+	return r.updateMeta(func(meta *RouteMeta) {
+		meta.Deprecated = true
+	})
+}
+
+// Meta 设置自定义元数据
+func (r *Route) Meta(key string, value interface{}) *Route {
+	return r.updateMeta(func(meta *RouteMeta) {
+		meta.Values[key] = value
+	})
+}
+
+// Remove 删除该路由, 可在处理请求的同时调用
+func (r *Route) Remove() error {
+	if r.failed {
+		return fmt.Errorf("zero: route %s %s was not registered", r.Method, r.Pattern)
+	}
+	return r.engine.removeRoute(r.Host, r.Method, r.Pattern)
+}
+
+// URL 根据路由名称和参数生成经过转义的路径
+// pairs 为参数名与参数值交替组成的列表, 例如 URL("user.show", "id", 42)
+func (e *Engine) URL(name string, pairs ...interface{}) (string, error) {
+	e.mu.RLock()
+	route, ok := e.namedRoutes[name]
+	e.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("zero: route %q does not exist", name)
+	}
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("zero: route %q expects key/value pairs, got %d arguments", name, len(pairs))
+	}
+	params := make(map[string]string, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		params[fmt.Sprint(pairs[i])] = fmt.Sprint(pairs[i+1])
+	}
+
+	var b strings.Builder
+	pattern := route.Pattern
+	for len(pattern) > 0 {
+		end := staticEnd(pattern)
+		b.WriteString(pattern[:end])
+		if end == len(pattern) {
+			break
+		}
+
+		pattern = pattern[end:]
+		wildcard := pattern[:wildcardEnd(pattern)]
+		if wildcard[0] == '*' {
+			// 通配参数允许包含 /, 逐段转义
+			wildcard = pattern
+		}
+		pattern = pattern[len(wildcard):]
+
+		key, _ := splitParam(wildcard)
+		if key == "" {
+			continue
+		}
+		value, ok := params[key]
+		if !ok {
+			return "", fmt.Errorf("zero: route %q is missing parameter %q", name, key)
+		}
+		if wildcard[0] == ':' {
+			b.WriteString(url.PathEscape(value))
+			continue
+		}
+		segments := strings.Split(value, "/")
+		for i, segment := range segments {
+			segments[i] = url.PathEscape(segment)
+		}
+		b.WriteString(strings.Join(segments, "/"))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,200 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEngine_URL(t *testing.T) {
+	engine := New()
+	handler := func(c *Context) {}
+	engine.GET("/users/:id", handler).Name("user.show")
+	engine.Group("/v1").GET("/files/*filepath", handler).Name("file")
+	engine.GET("/about", handler).Name("about")
+
+	tests := []struct {
+		name  string
+		pairs []interface{}
+		want  string
+	}{
+		{"user.show", []interface{}{"id", 42}, "/users/42"},
+		{"user.show", []interface{}{"id", "a b/c"}, "/users/a%20b%2Fc"},
+		{"file", []interface{}{"filepath", "css/main file.css"}, "/v1/files/css/main%20file.css"},
+		{"about", nil, "/about"},
+	}
+	for _, tt := range tests {
+		got, err := engine.URL(tt.name, tt.pairs...)
+		if err != nil || got != tt.want {
+			t.Fatalf("URL(%q, %v) = %q, %v, want %q", tt.name, tt.pairs, got, err, tt.want)
+		}
+	}
+
+	for _, pairs := range [][]interface{}{nil, {"id"}, {"name", "chenquan"}} {
+		if _, err := engine.URL("user.show", pairs...); err == nil {
+			t.Fatalf("URL with %v should fail", pairs)
+		}
+	}
+	if _, err := engine.URL("missing"); err == nil {
+		t.Fatal("unknown route name should fail")
+	}
+
+	engine.GET("/posts/:id", handler).Name("user.show")
+	if engine.Err() == nil {
+		t.Fatal("duplicate route name should be reported")
+	}
+
+	// 注册失败的路由不能被命名或删除
+	failed := engine.GET("/users/:uid", handler).Name("failed")
+	if _, err := engine.URL("failed", "uid", 1); err == nil {
+		t.Fatal("route rejected as a conflict should not be named")
+	}
+	if err := failed.Remove(); err == nil {
+		t.Fatal("route rejected as a conflict should not be removable")
+	}
+	if _, err := engine.URL("user.show", "id", 1); err != nil {
+		t.Fatalf("existing route should be kept: %v", err)
+	}
+}
+
+func TestEngine_LoadHTMLGlob_url(t *testing.T) {
+	dir, err := ioutil.TempDir("", "zero")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	err = ioutil.WriteFile(filepath.Join(dir, "user.html"), []byte(`<a href="{{url "user.show" "id" .}}">`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := New()
+	engine.GET("/users/:id", func(c *Context) {
+		c.HTML(http.StatusOK, "user.html", c.Param("id"))
+	}).Name("user.show")
+	engine.LoadHTMLGlob(filepath.Join(dir, "*.html"))
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(GET, "/users/7", nil))
+	if w.Body.String() != `<a href="/users/7">` {
+		t.Fatalf("body = %q", w.Body.String())
+	}
+}
+
+func handlerForRoutesTest(c *Context) {}
+
+func TestEngine_Routes(t *testing.T) {
+	engine := New()
+	engine.Use(func(c *Context) { c.Next() })
+	v1 := engine.Group("/v1")
+	v1.Use(func(c *Context) { c.Next() }, func(c *Context) { c.Next() })
+	v1.GET("/users/:id", handlerForRoutesTest).Name("user.show")
+	v1.POST("/users", handlerForRoutesTest, handlerForRoutesTest)
+	engine.GET("/ping", handlerForRoutesTest)
+	engine.GET("/ping", handlerForRoutesTest)
+
+	routes := engine.Routes()
+	want := []struct {
+		method      string
+		path        string
+		name        string
+		handlers    int
+		middlewares int
+	}{
+		{GET, "/ping", "", 1, 1},
+		{POST, "/v1/users", "", 2, 3},
+		{GET, "/v1/users/:id", "user.show", 1, 3},
+	}
+	if len(routes) != len(want) {
+		t.Fatalf("len(Routes()) = %d, want %d", len(routes), len(want))
+	}
+	for i, w := range want {
+		r := routes[i]
+		if r.Method != w.method || r.Path != w.path || r.Name != w.name || len(r.Handlers) != w.handlers || r.Middlewares != w.middlewares {
+			t.Fatalf("Routes()[%d] = %+v", i, r)
+		}
+		if r.Handlers[0] != "github.com/chenquan/zero.handlerForRoutesTest" {
+			t.Fatalf("handler name = %s", r.Handlers[0])
+		}
+	}
+	if table := routes.String(); !strings.Contains(table, "/v1/users/:id") || !strings.HasPrefix(table, "HOST") {
+		t.Fatalf("unexpected route table:\n%s", table)
+	}
+}
+
+func TestRoute_Metadata(t *testing.T) {
+	engine := New()
+	engine.Use(func(c *Context) {
+		meta := c.Metadata()
+		if meta.HasPermission("admin") && c.Req.Header.Get("X-Role") != "admin" {
+			c.Fail(http.StatusForbidden, "forbidden")
+			return
+		}
+		if meta.Deprecated {
+			c.SetHeader("Deprecation", "true")
+		}
+		if class, ok := meta.Value("owner"); ok {
+			c.SetHeader("X-Owner", class.(string))
+		}
+		c.Next()
+	})
+	handler := func(c *Context) {
+		c.String(http.StatusOK, c.Metadata().RateLimit)
+	}
+	engine.GET("/admin", handler).
+		Describe("admin panel").
+		Tag("admin", "internal").
+		Permit("admin").
+		RateLimit("strict").
+		Meta("owner", "ops")
+	engine.GET("/old", handler).Deprecate()
+
+	tests := []struct {
+		path   string
+		role   string
+		code   int
+		header string
+		value  string
+	}{
+		{"/admin", "", http.StatusForbidden, "X-Owner", ""},
+		{"/admin", "admin", http.StatusOK, "X-Owner", "ops"},
+		{"/old", "", http.StatusOK, "Deprecation", "true"},
+		{"/missing", "", http.StatusNotFound, "Deprecation", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(GET, tt.path, nil)
+		req.Header.Set("X-Role", tt.role)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != tt.code || w.Header().Get(tt.header) != tt.value {
+			t.Fatalf("%s: status = %d, %s = %q", tt.path, w.Code, tt.header, w.Header().Get(tt.header))
+		}
+	}
+
+	routes := engine.Routes()
+	meta := routes[0].Meta
+	if routes[0].Path != "/admin" || meta.Description != "admin panel" || !meta.HasTag("internal") || meta.RateLimit != "strict" {
+		t.Fatalf("Routes()[0].Meta = %+v", meta)
+	}
+}
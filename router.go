@@ -19,9 +19,15 @@ package zero
 
 import (
 	"log"
+	"net/http"
+	"net/url"
+	"path"
 	"reflect"
 	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // 支持的请求方式
@@ -37,18 +43,68 @@ const (
 	TRACE   = "TRACE"
 )
 
+// anyMethods Any 注册的全部请求方式
+var anyMethods = []string{GET, HEAD, POST, PUT, PATCH, DELETE, CONNECT, OPTIONS, TRACE}
+
+// Param 路径参数
+type Param struct {
+	Key   string
+	Value string
+}
+
+// Params 路径参数列表, 按在路由中出现的顺序排列
+type Params []Param
+
+// Get 返回参数名对应的值
+func (ps Params) Get(name string) (string, bool) {
+	for _, p := range ps {
+		if p.Key == name {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// ByName 返回参数名对应的值, 不存在时返回空字符串
+func (ps Params) ByName(name string) (value string) {
+	value, _ = ps.Get(name)
+	return
+}
+
+// routeTable 路由表快照, 发布后只读, 可被多个请求并发读取
+type routeTable struct {
+	roots     map[string]*node // key是网络请求方式
+	maxParams int              // 单条路由中参数的最大个数
+}
+
+// routeEntry 已注册的路由, 用于删除路由后重建路由树
+type routeEntry struct {
+	method    string
+	pattern   string
+	handlers  []HandlerFunc
+	route     *Route // 对应的 Engine 路由, 直接使用 router 时为空
+	numParams int
+}
+
 // router 路由
+// 读取时原子加载路由表快照, 无需加锁; 写入时加锁并以写时复制的方式发布新快照,
+// 因此可以在处理请求的同时添加或删除路由
 type router struct {
-	roots    map[string]*node // key是网络请求方式
-	handlers map[string][]HandlerFunc
+	mu      sync.Mutex   // 保护写操作
+	table   atomic.Value // *routeTable
+	entries []routeEntry // 按注册顺序排列的路由
 }
 
 // newRouter 新建路由
 func newRouter() *router {
-	return &router{
-		roots:    make(map[string]*node),
-		handlers: make(map[string][]HandlerFunc),
-	}
+	r := &router{}
+	r.table.Store(&routeTable{roots: make(map[string]*node)})
+	return r
+}
+
+// load 返回当前路由表快照
+func (r *router) load() *routeTable {
+	return r.table.Load().(*routeTable)
 }
 
 // parsePattern 解析Pattern
@@ -69,75 +125,312 @@ func parsePattern(pattern string) []string {
 	return parts
 }
 
-// addRoute 添加一个新的路由
-func (r *router) addRoute(method string, pattern string, handlers ...HandlerFunc) {
-	var fns []string
+// nameOfFunction 返回函数名称
+func nameOfFunction(f interface{}) string {
+	return runtime.FuncForPC(reflect.ValueOf(f).Pointer()).Name()
+}
+
+// handlerNames 返回处理函数名称列表
+func handlerNames(handlers []HandlerFunc) []string {
+	var names []string
 	for _, handler := range handlers {
-		fn := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
-		fns = append(fns, fn)
+		names = append(names, nameOfFunction(handler))
+	}
+	return names
+}
+
+// addRoute 添加一个新的路由
+// 路由格式错误、重复或与已注册路由冲突时返回 *RouteError, 此时不会注册该路由
+func (r *router) addRoute(method string, pattern string, handlers ...HandlerFunc) error {
+	return r.add(routeEntry{method: method, pattern: pattern, handlers: handlers})
+}
+
+// add 添加一个新的路由
+func (r *router) add(entry routeEntry) error {
+	method, pattern := entry.method, entry.pattern
+	if reason := validatePattern(pattern); reason != "" {
+		return &RouteError{Method: method, Pattern: pattern, Reason: reason}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := r.load()
+	root, err := t.insert(t.roots[method], entry)
+	if err != nil {
+		return err
 	}
-	handlerNames := ""
+
+	fns := handlerNames(entry.handlers)
+	names := ""
 	if len(fns) != 0 {
 
-		handlerNames = ": " + strings.Join(fns, ", ")
+		names = ": " + strings.Join(fns, ", ")
 	}
-	log.Printf("[ZERO] ROUTE %4s - %s, handlers(%d)%s", method, pattern, len(handlers), handlerNames)
+	log.Printf("[ZERO] ROUTE %4s - %s, handlers(%d)%s", method, pattern, len(entry.handlers), names)
 
-	parts := parsePattern(pattern)
-	key := method + "-" + pattern
-	_, ok := r.roots[method]
-	if !ok {
-		// 路由根节点
-		r.roots[method] = new(node)
+	entry.numParams = len(parseParamNames(pattern))
+	r.entries = append(r.entries, entry)
+	maxParams := t.maxParams
+	if entry.numParams > maxParams {
+		maxParams = entry.numParams
+	}
+	r.publish(t, method, root, maxParams)
+	return nil
+}
+
+// removeRoute 删除路由, 路由不存在时返回 false
+// 删除后按注册顺序重建该请求方式的路由树
+func (r *router) removeRoute(method string, pattern string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index := -1
+	for i, entry := range r.entries {
+		if entry.method == method && entry.pattern == pattern {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return false
+	}
+	r.entries = append(r.entries[:index:index], r.entries[index+1:]...)
+
+	var root *node
+	maxParams := 0
+	t := r.load()
+	for _, entry := range r.entries {
+		if entry.method == method {
+			// 已注册的路由之间不存在冲突, 重建不会失败
+			root, _ = t.insert(root, entry)
+		}
+		if entry.numParams > maxParams {
+			maxParams = entry.numParams
+		}
+	}
+	log.Printf("[ZERO] ROUTE %4s - %s, removed", method, pattern)
+	r.publish(t, method, root, maxParams)
+	return true
+}
+
+// publish 以 root 替换 method 对应的路由树, 发布新的路由表快照
+func (r *router) publish(old *routeTable, method string, root *node, maxParams int) {
+	t := &routeTable{roots: make(map[string]*node, len(old.roots)+1), maxParams: maxParams}
+	for m, n := range old.roots {
+		t.roots[m] = n
+	}
+	if root == nil {
+		delete(t.roots, method)
+	} else {
+		t.roots[method] = root
+	}
+	r.table.Store(t)
+}
+
+// insert 将路由插入 root 的拷贝中, 返回新的根节点, root 为空时新建路由树
+func (t *routeTable) insert(root *node, entry routeEntry) (*node, error) {
+	method, pattern := entry.method, entry.pattern
+	if root == nil {
+		root = new(node)
+	} else {
+		root = root.clone()
 	}
 	// 插入路径
-	r.roots[method].insert(pattern, parts, 0)
-	r.handlers[key] = handlers
+	n, err := root.insert(pattern)
+	if err != nil {
+		err.Method = method
+		return nil, err
+	}
+	if n.pattern != "" {
+		return nil, &RouteError{Method: method, Pattern: pattern, Conflict: n.pattern, Reason: "duplicate route"}
+	}
+	n.pattern = pattern
+	n.paramNames = parseParamNames(pattern)
+	n.handlers = entry.handlers
+	n.route = entry.route
+	return root, nil
 }
 
-// getRoute 获取路由节点和路径参数
-func (r *router) getRoute(method string, path string) (node *node, params map[string]string) {
-	root, ok := r.roots[method]
+// find 查找路由节点, 路径参数追加到 params 末尾, 调用方提供足够容量时不产生内存分配
+func (r *router) find(method string, path string, params *Params) *node {
+	return r.load().find(method, path, params)
+}
+
+// find 查找路由节点, 路径参数追加到 params 末尾, 调用方提供足够容量时不产生内存分配
+func (t *routeTable) find(method string, path string, params *Params) *node {
+	root, ok := t.roots[method]
 	if !ok {
-		return
+		return nil
 	}
 
-	searchParts := parsePattern(path)
-	// 存储路径参数
-	params = make(map[string]string)
+	start := len(*params)
+	n := root.search(path, params)
+	if n == nil {
+		*params = (*params)[:start]
+		return nil
+	}
+	for i, name := range n.paramNames {
+		(*params)[start+i].Key = name
+	}
+	return n
+}
 
-	// 获取路由节点
-	node = root.search(searchParts, 0)
+// getRoute 获取路由节点和路径参数
+func (r *router) getRoute(method string, path string) (n *node, params Params) {
+	params = make(Params, 0, r.load().maxParams)
+	n = r.find(method, path, &params)
+	return
+}
 
-	if node != nil {
-		parts := parsePattern(node.pattern)
-		for index, part := range parts {
-			if part[0] == ':' {
-				params[part[1:]] = searchParts[index]
-			}
-			if part[0] == '*' && len(part) > 1 {
-				params[part[1:]] = strings.Join(searchParts[index:], "/")
-				break
+// cleanPath 规范化路径: 合并重复的 /, 处理 . 与 .., 保留末尾的 /
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	if p[0] != '/' {
+		p = "/" + p
+	}
+	np := path.Clean(p)
+	if p[len(p)-1] == '/' && np != "/" {
+		np += "/"
+	}
+	return np
+}
+
+// toggleTrailingSlash 添加或去除末尾的 /
+func toggleTrailingSlash(p string) string {
+	if strings.HasSuffix(p, "/") {
+		return p[:len(p)-1]
+	}
+	return p + "/"
+}
+
+// redirectPath 返回请求路径对应的已注册规范路径, 不存在时返回空字符串
+// trailingSlash 允许添加或去除末尾的 /, fixedPath 允许清理路径并忽略大小写
+func (t *routeTable) redirectPath(method string, p string, trailingSlash bool, fixedPath bool) string {
+	root, ok := t.roots[method]
+	if !ok {
+		return ""
+	}
+
+	params := make(Params, 0, t.maxParams)
+	if trailingSlash {
+		if alt := toggleTrailingSlash(p); alt != "" && t.find(method, alt, &params) != nil {
+			return alt
+		}
+	}
+	if fixedPath {
+		cleaned := cleanPath(p)
+		buf := make([]byte, 0, len(cleaned)+1)
+		if fixed := root.searchFold(cleaned, buf); fixed != nil {
+			return string(fixed)
+		}
+		if trailingSlash {
+			if alt := toggleTrailingSlash(cleaned); alt != "" {
+				if fixed := root.searchFold(alt, buf); fixed != nil {
+					return string(fixed)
+				}
 			}
 		}
-		return
 	}
-	return
+	return ""
 }
 
-func (r *router) handle(c *Context) {
+// allowed 返回能够匹配 path 的其他请求方式, 用于 Allow 响应头
+// path 为 * 时返回所有已注册的请求方式
+func (t *routeTable) allowed(method string, path string) []string {
+	var methods []string
+	params := make(Params, 0, t.maxParams)
+	for m := range t.roots {
+		if m == method {
+			continue
+		}
+		if path == "*" || t.find(m, path, &params) != nil {
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+// handle 处理请求, fallback 为 false 时路径未匹配到任何路由则不处理, 返回 false,
+// 由调用方交给其他路由处理
+func (r *router) handle(c *Context, fallback bool) bool {
+	// 整个请求使用同一份路由表快照
+	t := r.load()
+	if cap(c.Params)-len(c.Params) < t.maxParams {
+		params := make(Params, len(c.Params), len(c.Params)+t.maxParams)
+		copy(params, c.Params)
+		c.Params = params
+	}
 	// 获取路由
-	n, params := r.getRoute(c.Method, c.Path)
+	n := t.find(c.Method, c.Path, &c.Params)
 	if n != nil {
-		c.Params = params
-		key := c.Method + "-" + n.pattern
-		c.handlers = append(c.handlers, r.handlers[key]...)
+		c.handlers = n.handlers
+		c.route = n.route
+		c.Next()
+		return true
+	}
 
-	} else {
-		c.handlers = append(c.handlers, func(c *Context) {
-			panic(NotFoundError)
-		})
+	// 未匹配到路由时, 使用全局中间件
+	middlewares := c.engine.RouterGroup.middlewares
+	if c.Method != CONNECT && c.Path != "/" {
+		location := t.redirectPath(c.Method, c.Path, c.engine.RedirectTrailingSlash, c.engine.RedirectFixedPath)
+		if location != "" && location != c.Path && isLocalPath(location) {
+			// 路径是解码后的, 需重新转义, 避免参数中的 ? 或 # 改变跳转目标
+			location = (&url.URL{Path: location}).EscapedPath()
+			if c.Req.URL.RawQuery != "" {
+				location += "?" + c.Req.URL.RawQuery
+			}
+			c.handlers = combineHandlers(middlewares, []HandlerFunc{redirect(location)})
+			c.Next()
+			return true
+		}
+	}
+
+	allow := t.allowed(c.Method, c.Path)
+	if len(allow) == 0 && !fallback {
+		return false
+	}
+	switch {
+	case len(allow) == 0:
+		c.handlers = combineHandlers(middlewares, c.engine.noRoute)
+	case c.Method == OPTIONS && c.engine.HandleOPTIONS:
+		allow = append(allow, OPTIONS)
+		c.handlers = combineHandlers(middlewares, []HandlerFunc{options})
+	default:
+		c.handlers = combineHandlers(middlewares, c.engine.noMethod)
+	}
+	if len(allow) > 0 {
+		sort.Strings(allow)
+		c.SetHeader("Allow", strings.Join(allow, ", "))
 	}
 	c.Next()
+	return true
+}
+
+// isLocalPath 是否为本站路径
+// 以 // 或 /\ 开头的路径会被浏览器视为其他域名, 不能用作跳转目标
+func isLocalPath(location string) bool {
+	if location == "" || location[0] != '/' {
+		return false
+	}
+	return len(location) == 1 || location[1] != '/' && location[1] != '\\'
+}
+
+// options 自动响应 OPTIONS 请求
+func options(c *Context) {
+	c.Status(http.StatusNoContent)
+}
 
+// redirect 永久重定向到规范路径, GET 请求使用 301, 其余请求使用 308 以保留请求方式和请求体
+func redirect(location string) HandlerFunc {
+	return func(c *Context) {
+		code := http.StatusMovedPermanently
+		if c.Method != GET {
+			code = http.StatusPermanentRedirect
+		}
+		c.StatusCode = code
+		http.Redirect(c.Res, c.Req, location, code)
+	}
 }
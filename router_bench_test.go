@@ -0,0 +1,459 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// legacyNode 旧版线性扫描前缀树, 仅用于基准对比
+type legacyNode struct {
+	pattern  string
+	part     string
+	children []*legacyNode
+	isWild   bool
+}
+
+func (n *legacyNode) matchChild(part string) *legacyNode {
+	for _, child := range n.children {
+		if child.part == part || child.isWild {
+			return child
+		}
+	}
+	return nil
+}
+
+func (n *legacyNode) matchChildren(part string) []*legacyNode {
+	nodes := make([]*legacyNode, 0)
+	for _, child := range n.children {
+		if child.part == part || child.isWild {
+			nodes = append(nodes, child)
+		}
+	}
+	return nodes
+}
+
+func (n *legacyNode) insert(pattern string, parts []string, depth int) {
+	if len(parts) == depth {
+		n.pattern = pattern
+		return
+	}
+	part := parts[depth]
+	child := n.matchChild(part)
+	if child == nil {
+		child = &legacyNode{part: part, isWild: part[0] == ':' || part[0] == '*'}
+		n.children = append(n.children, child)
+	}
+	child.insert(pattern, parts, depth+1)
+}
+
+func (n *legacyNode) search(parts []string, depth int) *legacyNode {
+	if len(parts) == depth || strings.HasPrefix(n.part, "*") {
+		if n.pattern == "" {
+			return nil
+		}
+		return n
+	}
+	for _, child := range n.matchChildren(parts[depth]) {
+		if result := child.search(parts, depth+1); result != nil {
+			return result
+		}
+	}
+	return nil
+}
+
+// legacyRouter 旧版 router.getRoute 的实现
+type legacyRouter struct {
+	roots map[string]*legacyNode
+}
+
+func (r *legacyRouter) addRoute(method string, pattern string) {
+	if _, ok := r.roots[method]; !ok {
+		r.roots[method] = new(legacyNode)
+	}
+	r.roots[method].insert(pattern, parsePattern(pattern), 0)
+}
+
+func (r *legacyRouter) getRoute(method string, path string) (*legacyNode, map[string]string) {
+	root, ok := r.roots[method]
+	if !ok {
+		return nil, nil
+	}
+	searchParts := parsePattern(path)
+	params := make(map[string]string)
+	n := root.search(searchParts, 0)
+	if n != nil {
+		for index, part := range parsePattern(n.pattern) {
+			if part[0] == ':' {
+				params[part[1:]] = searchParts[index]
+			}
+			if part[0] == '*' && len(part) > 1 {
+				params[part[1:]] = strings.Join(searchParts[index:], "/")
+				break
+			}
+		}
+	}
+	return n, params
+}
+
+type benchRoute struct {
+	method string
+	path   string
+}
+
+// githubAPI GitHub API 路由, 静态与参数路由混合
+var githubAPI = []benchRoute{
+	{GET, "/authorizations"},
+	{GET, "/authorizations/:id"},
+	{POST, "/authorizations"},
+	{DELETE, "/authorizations/:id"},
+	{GET, "/applications/:client_id/tokens/:access_token"},
+	{DELETE, "/applications/:client_id/tokens"},
+	{DELETE, "/applications/:client_id/tokens/:access_token"},
+	{GET, "/events"},
+	{GET, "/repos/:owner/:repo/events"},
+	{GET, "/networks/:owner/:repo/events"},
+	{GET, "/orgs/:org/events"},
+	{GET, "/users/:user/received_events"},
+	{GET, "/users/:user/received_events/public"},
+	{GET, "/users/:user/events"},
+	{GET, "/users/:user/events/public"},
+	{GET, "/users/:user/events/orgs/:org"},
+	{GET, "/feeds"},
+	{GET, "/notifications"},
+	{GET, "/repos/:owner/:repo/notifications"},
+	{PUT, "/notifications"},
+	{PUT, "/repos/:owner/:repo/notifications"},
+	{GET, "/notifications/threads/:id"},
+	{GET, "/notifications/threads/:id/subscription"},
+	{PUT, "/notifications/threads/:id/subscription"},
+	{DELETE, "/notifications/threads/:id/subscription"},
+	{GET, "/repos/:owner/:repo/stargazers"},
+	{GET, "/users/:user/starred"},
+	{GET, "/user/starred"},
+	{GET, "/user/starred/:owner/:repo"},
+	{PUT, "/user/starred/:owner/:repo"},
+	{DELETE, "/user/starred/:owner/:repo"},
+	{GET, "/repos/:owner/:repo/subscribers"},
+	{GET, "/users/:user/subscriptions"},
+	{GET, "/user/subscriptions"},
+	{GET, "/repos/:owner/:repo/subscription"},
+	{PUT, "/repos/:owner/:repo/subscription"},
+	{DELETE, "/repos/:owner/:repo/subscription"},
+	{GET, "/user/subscriptions/:owner/:repo"},
+	{PUT, "/user/subscriptions/:owner/:repo"},
+	{DELETE, "/user/subscriptions/:owner/:repo"},
+	{GET, "/users/:user/gists"},
+	{GET, "/gists"},
+	{GET, "/gists/:id"},
+	{POST, "/gists"},
+	{PUT, "/gists/:id/star"},
+	{DELETE, "/gists/:id/star"},
+	{GET, "/gists/:id/star"},
+	{POST, "/gists/:id/forks"},
+	{DELETE, "/gists/:id"},
+	{GET, "/repos/:owner/:repo/git/blobs/:sha"},
+	{POST, "/repos/:owner/:repo/git/blobs"},
+	{GET, "/repos/:owner/:repo/git/commits/:sha"},
+	{POST, "/repos/:owner/:repo/git/commits"},
+	{GET, "/repos/:owner/:repo/git/refs"},
+	{POST, "/repos/:owner/:repo/git/refs"},
+	{GET, "/repos/:owner/:repo/git/tags/:sha"},
+	{POST, "/repos/:owner/:repo/git/tags"},
+	{GET, "/repos/:owner/:repo/git/trees/:sha"},
+	{POST, "/repos/:owner/:repo/git/trees"},
+	{GET, "/issues"},
+	{GET, "/user/issues"},
+	{GET, "/orgs/:org/issues"},
+	{GET, "/repos/:owner/:repo/issues"},
+	{GET, "/repos/:owner/:repo/issues/:number"},
+	{POST, "/repos/:owner/:repo/issues"},
+	{GET, "/repos/:owner/:repo/assignees"},
+	{GET, "/repos/:owner/:repo/assignees/:assignee"},
+	{GET, "/repos/:owner/:repo/issues/:number/comments"},
+	{POST, "/repos/:owner/:repo/issues/:number/comments"},
+	{GET, "/repos/:owner/:repo/issues/:number/events"},
+	{GET, "/repos/:owner/:repo/labels"},
+	{GET, "/repos/:owner/:repo/labels/:name"},
+	{POST, "/repos/:owner/:repo/labels"},
+	{DELETE, "/repos/:owner/:repo/labels/:name"},
+	{GET, "/repos/:owner/:repo/milestones"},
+	{GET, "/repos/:owner/:repo/milestones/:number"},
+	{POST, "/repos/:owner/:repo/milestones"},
+	{DELETE, "/repos/:owner/:repo/milestones/:number"},
+	{GET, "/emojis"},
+	{GET, "/gitignore/templates"},
+	{GET, "/gitignore/templates/:name"},
+	{POST, "/markdown"},
+	{POST, "/markdown/raw"},
+	{GET, "/meta"},
+	{GET, "/rate_limit"},
+	{GET, "/users/:user/orgs"},
+	{GET, "/user/orgs"},
+	{GET, "/orgs/:org"},
+	{GET, "/orgs/:org/members"},
+	{GET, "/orgs/:org/members/:user"},
+	{DELETE, "/orgs/:org/members/:user"},
+	{GET, "/orgs/:org/public_members"},
+	{GET, "/orgs/:org/public_members/:user"},
+	{PUT, "/orgs/:org/public_members/:user"},
+	{DELETE, "/orgs/:org/public_members/:user"},
+	{GET, "/orgs/:org/teams"},
+	{GET, "/teams/:id"},
+	{POST, "/orgs/:org/teams"},
+	{DELETE, "/teams/:id"},
+	{GET, "/teams/:id/members"},
+	{GET, "/teams/:id/members/:user"},
+	{PUT, "/teams/:id/members/:user"},
+	{DELETE, "/teams/:id/members/:user"},
+	{GET, "/teams/:id/repos"},
+	{GET, "/teams/:id/repos/:owner/:repo"},
+	{PUT, "/teams/:id/repos/:owner/:repo"},
+	{DELETE, "/teams/:id/repos/:owner/:repo"},
+	{GET, "/user/teams"},
+	{GET, "/repos/:owner/:repo/pulls"},
+	{GET, "/repos/:owner/:repo/pulls/:number"},
+	{POST, "/repos/:owner/:repo/pulls"},
+	{GET, "/repos/:owner/:repo/pulls/:number/commits"},
+	{GET, "/repos/:owner/:repo/pulls/:number/files"},
+	{GET, "/repos/:owner/:repo/pulls/:number/merge"},
+	{PUT, "/repos/:owner/:repo/pulls/:number/merge"},
+	{GET, "/repos/:owner/:repo/pulls/:number/comments"},
+	{PUT, "/repos/:owner/:repo/pulls/:number/comments"},
+	{GET, "/user/repos"},
+	{GET, "/users/:user/repos"},
+	{GET, "/orgs/:org/repos"},
+	{GET, "/repositories"},
+	{POST, "/user/repos"},
+	{POST, "/orgs/:org/repos"},
+	{GET, "/repos/:owner/:repo"},
+	{DELETE, "/repos/:owner/:repo"},
+	{GET, "/repos/:owner/:repo/contributors"},
+	{GET, "/repos/:owner/:repo/languages"},
+	{GET, "/repos/:owner/:repo/teams"},
+	{GET, "/repos/:owner/:repo/tags"},
+	{GET, "/repos/:owner/:repo/branches"},
+	{GET, "/repos/:owner/:repo/branches/:branch"},
+	{GET, "/repos/:owner/:repo/collaborators"},
+	{GET, "/repos/:owner/:repo/collaborators/:user"},
+	{PUT, "/repos/:owner/:repo/collaborators/:user"},
+	{DELETE, "/repos/:owner/:repo/collaborators/:user"},
+	{GET, "/repos/:owner/:repo/comments"},
+	{GET, "/repos/:owner/:repo/commits/:sha/comments"},
+	{POST, "/repos/:owner/:repo/commits/:sha/comments"},
+	{GET, "/repos/:owner/:repo/comments/:id"},
+	{DELETE, "/repos/:owner/:repo/comments/:id"},
+	{GET, "/repos/:owner/:repo/commits"},
+	{GET, "/repos/:owner/:repo/commits/:sha"},
+	{GET, "/repos/:owner/:repo/readme"},
+	{GET, "/repos/:owner/:repo/keys"},
+	{GET, "/repos/:owner/:repo/keys/:id"},
+	{POST, "/repos/:owner/:repo/keys"},
+	{DELETE, "/repos/:owner/:repo/keys/:id"},
+	{GET, "/repos/:owner/:repo/downloads"},
+	{GET, "/repos/:owner/:repo/downloads/:id"},
+	{DELETE, "/repos/:owner/:repo/downloads/:id"},
+	{GET, "/repos/:owner/:repo/forks"},
+	{POST, "/repos/:owner/:repo/forks"},
+	{GET, "/repos/:owner/:repo/hooks"},
+	{GET, "/repos/:owner/:repo/hooks/:id"},
+	{POST, "/repos/:owner/:repo/hooks"},
+	{POST, "/repos/:owner/:repo/hooks/:id/tests"},
+	{DELETE, "/repos/:owner/:repo/hooks/:id"},
+	{POST, "/repos/:owner/:repo/merges"},
+	{GET, "/repos/:owner/:repo/releases"},
+	{GET, "/repos/:owner/:repo/releases/:id"},
+	{POST, "/repos/:owner/:repo/releases"},
+	{DELETE, "/repos/:owner/:repo/releases/:id"},
+	{GET, "/repos/:owner/:repo/releases/:id/assets"},
+	{GET, "/repos/:owner/:repo/stats/contributors"},
+	{GET, "/repos/:owner/:repo/stats/commit_activity"},
+	{GET, "/repos/:owner/:repo/stats/code_frequency"},
+	{GET, "/repos/:owner/:repo/stats/participation"},
+	{GET, "/repos/:owner/:repo/stats/punch_card"},
+	{GET, "/repos/:owner/:repo/statuses/:ref"},
+	{POST, "/repos/:owner/:repo/statuses/:ref"},
+	{GET, "/search/repositories"},
+	{GET, "/search/code"},
+	{GET, "/search/issues"},
+	{GET, "/search/users"},
+	{GET, "/legacy/issues/search/:owner/:repository/:state/:keyword"},
+	{GET, "/legacy/repos/search/:keyword"},
+	{GET, "/legacy/user/search/:keyword"},
+	{GET, "/legacy/user/email/:email"},
+	{GET, "/users/:user"},
+	{GET, "/user"},
+	{GET, "/users"},
+	{GET, "/user/emails"},
+	{POST, "/user/emails"},
+	{DELETE, "/user/emails"},
+	{GET, "/users/:user/followers"},
+	{GET, "/user/followers"},
+	{GET, "/users/:user/following"},
+	{GET, "/user/following"},
+	{GET, "/user/following/:user"},
+	{GET, "/users/:user/following/:target_user"},
+	{PUT, "/user/following/:user"},
+	{DELETE, "/user/following/:user"},
+	{GET, "/users/:user/keys"},
+	{GET, "/user/keys"},
+	{GET, "/user/keys/:id"},
+	{POST, "/user/keys"},
+	{DELETE, "/user/keys/:id"},
+	{GET, "/static/*filepath"},
+}
+
+// benchRequests 基准测试请求, 覆盖静态、参数、多参数与通配路由
+var benchRequests = []benchRoute{
+	{GET, "/user/repos"},
+	{GET, "/repos/chenquan/zero/stargazers"},
+	{GET, "/repos/chenquan/zero/issues/42/comments"},
+	{GET, "/legacy/issues/search/chenquan/zero/open/router"},
+	{GET, "/static/css/bootstrap/main.min.css"},
+}
+
+func newBenchRouter() *router {
+	r := newRouter()
+	for _, route := range githubAPI {
+		r.addRoute(route.method, route.path, nil)
+	}
+	return r
+}
+
+func newLegacyBenchRouter() *legacyRouter {
+	r := &legacyRouter{roots: make(map[string]*legacyNode)}
+	for _, route := range githubAPI {
+		r.addRoute(route.method, route.path)
+	}
+	return r
+}
+
+func benchmarkRouter(b *testing.B, request benchRoute) {
+	r := newBenchRouter()
+	params := make(Params, 0, r.load().maxParams)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		params = params[:0]
+		if r.find(request.method, request.path, &params) == nil {
+			b.Fatalf("%s should be matched", request.path)
+		}
+	}
+}
+
+func benchmarkLegacyRouter(b *testing.B, request benchRoute) {
+	r := newLegacyBenchRouter()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if n, _ := r.getRoute(request.method, request.path); n == nil {
+			b.Fatalf("%s should be matched", request.path)
+		}
+	}
+}
+
+func BenchmarkRouter_Static(b *testing.B)   { benchmarkRouter(b, benchRequests[0]) }
+func BenchmarkRouter_Param(b *testing.B)    { benchmarkRouter(b, benchRequests[1]) }
+func BenchmarkRouter_Param2(b *testing.B)   { benchmarkRouter(b, benchRequests[2]) }
+func BenchmarkRouter_Param4(b *testing.B)   { benchmarkRouter(b, benchRequests[3]) }
+func BenchmarkRouter_CatchAll(b *testing.B) { benchmarkRouter(b, benchRequests[4]) }
+func BenchmarkLegacy_Static(b *testing.B)   { benchmarkLegacyRouter(b, benchRequests[0]) }
+func BenchmarkLegacy_Param(b *testing.B)    { benchmarkLegacyRouter(b, benchRequests[1]) }
+func BenchmarkLegacy_Param2(b *testing.B)   { benchmarkLegacyRouter(b, benchRequests[2]) }
+func BenchmarkLegacy_Param4(b *testing.B)   { benchmarkLegacyRouter(b, benchRequests[3]) }
+func BenchmarkLegacy_CatchAll(b *testing.B) { benchmarkLegacyRouter(b, benchRequests[4]) }
+
+// BenchmarkRouter_GithubAll 依次匹配全部 GitHub API 路由
+func BenchmarkRouter_GithubAll(b *testing.B) {
+	r := newBenchRouter()
+	params := make(Params, 0, r.load().maxParams)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, route := range githubAPI {
+			params = params[:0]
+			r.find(route.method, route.path, &params)
+		}
+	}
+}
+
+// BenchmarkLegacy_GithubAll 旧版前缀树依次匹配全部 GitHub API 路由
+func BenchmarkLegacy_GithubAll(b *testing.B) {
+	r := newLegacyBenchRouter()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, route := range githubAPI {
+			r.getRoute(route.method, route.path)
+		}
+	}
+}
+
+// mockResponseWriter 不产生内存分配的 http.ResponseWriter
+type mockResponseWriter struct {
+	header http.Header
+}
+
+func (m *mockResponseWriter) Header() http.Header {
+	return m.header
+}
+
+func (m *mockResponseWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (m *mockResponseWriter) WriteHeader(int) {}
+
+// benchmarkEngine 基准测试 Engine.ServeHTTP 的完整请求分发, 包括 Context 获取与回收
+func benchmarkEngine(b *testing.B, pattern string, path string) {
+	engine := New()
+	for _, route := range githubAPI {
+		engine.Handle(route.method, route.path, func(c *Context) {})
+	}
+	engine.Use(func(c *Context) { c.Next() })
+	engine.GET(pattern, func(c *Context) {
+		c.Param("id")
+	})
+
+	w := &mockResponseWriter{header: make(http.Header)}
+	req := httptest.NewRequest(GET, path, nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		engine.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkEngine_Static(b *testing.B) {
+	benchmarkEngine(b, "/bench/static", "/bench/static")
+}
+
+func BenchmarkEngine_Param(b *testing.B) {
+	benchmarkEngine(b, "/bench/:id", "/bench/42")
+}
+
+func BenchmarkEngine_Param3(b *testing.B) {
+	benchmarkEngine(b, "/bench/:id/:name/:tab", "/bench/42/chenquan/repos")
+}
+
+func BenchmarkEngine_Wildcard(b *testing.B) {
+	benchmarkEngine(b, "/bench/files/*filepath", "/bench/files/css/bootstrap/main.min.css")
+}
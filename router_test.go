@@ -19,6 +19,10 @@ package zero
 
 import (
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
 	"testing"
 )
 
@@ -49,21 +53,483 @@ func Test_router_getRoute(t *testing.T) {
 		t.Fatal("should match /hello/:name")
 	}
 
-	if ps["name"] != "chenquan" {
+	if ps.ByName("name") != "chenquan" {
 		t.Fatal("name should be equal to 'chenquan'")
 	}
-	fmt.Printf("matched path: %s, params['name']: %s\n", n.pattern, ps["name"])
+	fmt.Printf("matched path: %s, params['name']: %s\n", n.pattern, ps.ByName("name"))
 	n, ps = r.getRoute("GET", "/test/11/chenquan")
-	if ps["id"] != "11" {
+	if ps.ByName("id") != "11" {
 		t.Fatal("name should be equal to 'chenquan'")
 	}
-	if ps["name"] != "chenquan" {
+	if ps.ByName("name") != "chenquan" {
 		t.Fatal("name should be equal to 'chenquan'")
 	}
-	fmt.Printf("matched path: %s, params['id']: %s and ['name']: %s\n", n.pattern, ps["id"], ps["name"])
+	fmt.Printf("matched path: %s, params['id']: %s and ['name']: %s\n", n.pattern, ps.ByName("id"), ps.ByName("name"))
 
 }
 
+func Test_router_getRoute_priority(t *testing.T) {
+	r := newRouter()
+	r.addRoute("GET", "/users/new", nil)
+	r.addRoute("GET", "/users/:id", nil)
+	r.addRoute("GET", "/users/:id/posts", nil)
+	r.addRoute("GET", "/users/:id/*rest", nil)
+	r.addRoute("GET", "/user_list", nil)
+	r.addRoute("GET", "/src/*filepath", nil)
+
+	tests := []struct {
+		path    string
+		pattern string
+		params  Params
+	}{
+		{"/users/new", "/users/new", Params{}},
+		{"/users/newer", "/users/:id", Params{{"id", "newer"}}},
+		{"/users/42", "/users/:id", Params{{"id", "42"}}},
+		{"/users/42/posts", "/users/:id/posts", Params{{"id", "42"}}},
+		{"/users/42/comments/1", "/users/:id/*rest", Params{{"id", "42"}, {"rest", "comments/1"}}},
+		{"/user_list", "/user_list", Params{}},
+		{"/src/", "/src/*filepath", Params{{"filepath", ""}}},
+		{"/src/a/b.go", "/src/*filepath", Params{{"filepath", "a/b.go"}}},
+		{"/users", "", nil},
+		{"/user", "", nil},
+		{"/nope", "", nil},
+	}
+	for _, tt := range tests {
+		n, ps := r.getRoute("GET", tt.path)
+		if tt.pattern == "" {
+			if n != nil {
+				t.Fatalf("%s: expected no match, got %s", tt.path, n.pattern)
+			}
+			continue
+		}
+		if n == nil || n.pattern != tt.pattern {
+			t.Fatalf("%s: should match %s", tt.path, tt.pattern)
+		}
+		if !reflect.DeepEqual(ps, tt.params) {
+			t.Fatalf("%s: params = %v, want %v", tt.path, ps, tt.params)
+		}
+	}
+}
+
+func Test_router_find_allocs(t *testing.T) {
+	r := newTestRouter()
+	params := make(Params, 0, r.load().maxParams)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.find("GET", "/test/11/chenquan", &params)
+		params = params[:0]
+		r.find("GET", "/assets/css/main.css", &params)
+		params = params[:0]
+		r.find("GET", "/hello/b/c", &params)
+	})
+	if allocs != 0 {
+		t.Fatalf("find allocated %v times per run", allocs)
+	}
+}
+
+func Test_router_addRoute_conflict(t *testing.T) {
+	tests := []struct {
+		existing string
+		pattern  string
+		conflict string
+	}{
+		{"/hello/:name", "/hello/:id", "/hello/:name"},
+		{"/hello/:name/a", "/hello/:id/b", "/hello/:name/a"},
+		{"/hello/:name", "/hello/:name", "/hello/:name"},
+		{"/src/*filepath", "/src/*path", "/src/*filepath"},
+		{"/hello/:name", "/hello/*rest", "/hello/:name"},
+		{"/hello/*rest", "/hello/:name", "/hello/*rest"},
+		{"/hello/:name/x", "/hello/*rest", "/hello/:name/x"},
+		{"/hello", "/hello/*rest/x", ""},
+		{"/hello", "/hello/:", ""},
+		{"/hello", "/hello/:a:b", ""},
+		{"/hello", "hello", ""},
+	}
+	for _, tt := range tests {
+		r := newRouter()
+		if err := r.addRoute("GET", tt.existing, nil); err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.existing, err)
+		}
+		err := r.addRoute("GET", tt.pattern, nil)
+		routeErr, ok := err.(*RouteError)
+		if !ok {
+			t.Fatalf("%s: expected *RouteError, got %v", tt.pattern, err)
+		}
+		if routeErr.Pattern != tt.pattern || routeErr.Conflict != tt.conflict {
+			t.Fatalf("%s: unexpected error %v", tt.pattern, err)
+		}
+	}
+
+	r := newRouter()
+	for _, pattern := range []string{"/hello/:name", "/hello/new", "/hello/:name/x", "/hello/:id<int>", "/hello/new/*rest"} {
+		if err := r.addRoute("GET", pattern, nil); err != nil {
+			t.Fatalf("%s: unexpected error %v", pattern, err)
+		}
+	}
+	if err := r.addRoute("POST", "/hello/:id", nil); err != nil {
+		t.Fatalf("routes of different methods should not conflict: %v", err)
+	}
+}
+
+func Test_router_getRoute_constraint(t *testing.T) {
+	r := newRouter()
+	for _, pattern := range []string{
+		"/users/:id<int>",
+		"/users/:uuid<uuid>/posts",
+		"/users/:name",
+		"/users/:name/posts",
+		"/files/:name<[a-z]+\\.txt>",
+		"/files/*filepath",
+	} {
+		if err := r.addRoute("GET", pattern, nil); err != nil {
+			t.Fatalf("%s: unexpected error %v", pattern, err)
+		}
+	}
+
+	tests := []struct {
+		path    string
+		pattern string
+		params  Params
+	}{
+		{"/users/42", "/users/:id<int>", Params{{"id", "42"}}},
+		{"/users/chenquan", "/users/:name", Params{{"name", "chenquan"}}},
+		{"/users/0b7a9c3e-1f2d-4e5f-8a9b-0c1d2e3f4a5b/posts", "/users/:uuid<uuid>/posts", Params{{"uuid", "0b7a9c3e-1f2d-4e5f-8a9b-0c1d2e3f4a5b"}}},
+		{"/users/42/posts", "/users/:name/posts", Params{{"name", "42"}}},
+		{"/files/a.txt", "/files/:name<[a-z]+\\.txt>", Params{{"name", "a.txt"}}},
+		{"/files/a.go", "/files/*filepath", Params{{"filepath", "a.go"}}},
+	}
+	for _, tt := range tests {
+		n, ps := r.getRoute("GET", tt.path)
+		if n == nil || n.pattern != tt.pattern {
+			t.Fatalf("%s: should match %s", tt.path, tt.pattern)
+		}
+		if !reflect.DeepEqual(ps, tt.params) {
+			t.Fatalf("%s: params = %v, want %v", tt.path, ps, tt.params)
+		}
+	}
+
+	for _, pattern := range []string{"/users/:uid<int>", "/users/:id<int", "/users/:id<>", "/users/:id<[>", "/files/*path<int>"} {
+		if err := r.addRoute("GET", pattern, nil); err == nil {
+			t.Fatalf("%s: should be rejected", pattern)
+		}
+	}
+}
+
+func TestEngine_StrictRouting(t *testing.T) {
+	engine := New()
+	engine.GET("/hello/:name", nil)
+	if engine.Err() != nil {
+		t.Fatalf("unexpected route error %v", engine.Err())
+	}
+	engine.GET("/hello/:id", nil)
+	if _, ok := engine.Err().(*RouteError); !ok {
+		t.Fatalf("Err = %v, want *RouteError", engine.Err())
+	}
+	if engine.Run("") == nil {
+		t.Fatal("Run should return the route error")
+	}
+
+	engine = New()
+	engine.StrictRouting = true
+	engine.GET("/hello/:name", nil)
+	defer func() {
+		if recover() == nil {
+			t.Fatal("conflicting route should panic in strict mode")
+		}
+	}()
+	engine.GET("/hello/:id", nil)
+}
+
+func TestEngine_NoMethod(t *testing.T) {
+	engine := New()
+	engine.GET("/users/:id", func(c *Context) {})
+	engine.PUT("/users/:id", func(c *Context) {})
+	engine.DELETE("/users/:id", func(c *Context) {})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("POST", "/users/1", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want 405", w.Code)
+	}
+	if allow := w.Header().Get("Allow"); allow != "DELETE, GET, PUT" {
+		t.Fatalf("Allow = %q", allow)
+	}
+
+	var middleware bool
+	engine.Use(func(c *Context) {
+		middleware = true
+		c.Next()
+	})
+	engine.NoMethod(func(c *Context) {
+		c.String(http.StatusMethodNotAllowed, "allow: %s", c.Res.Header().Get("Allow"))
+	})
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("PATCH", "/users/1", nil))
+	if !middleware || w.Body.String() != "allow: DELETE, GET, PUT" {
+		t.Fatalf("custom NoMethod handlers not applied, body = %q", w.Body.String())
+	}
+}
+
+func TestEngine_NoRoute(t *testing.T) {
+	engine := New()
+	engine.GET("/users/:id", func(c *Context) {})
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/posts/1", nil))
+	if w.Code != http.StatusNotFound || w.Body.String() != NotFoundError.Error() {
+		t.Fatalf("status = %d, body = %q", w.Code, w.Body.String())
+	}
+
+	var middleware bool
+	engine.Use(func(c *Context) {
+		middleware = true
+		c.Next()
+	})
+	engine.NoRoute(func(c *Context) {
+		c.JSON(http.StatusNotFound, Z{"path": c.Path})
+	})
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest("GET", "/posts/1", nil))
+	if !middleware || w.Code != http.StatusNotFound || w.Body.String() != "{\"path\":\"/posts/1\"}\n" {
+		t.Fatalf("custom NoRoute handlers not applied, body = %q", w.Body.String())
+	}
+}
+
+func TestRouterGroup_methods(t *testing.T) {
+	engine := New()
+	v1 := engine.Group("/v1")
+	handler := func(c *Context) {
+		c.String(http.StatusOK, "%s %s", c.Method, c.Path)
+	}
+	v1.DELETE("/delete", handler)
+	v1.PATCH("/patch", handler)
+	v1.OPTIONS("/options", handler)
+	v1.Handle(TRACE, "/trace", handler)
+	v1.Match([]string{GET, POST}, "/match", handler)
+	v1.Any("/any", handler)
+	engine.PATCH("/patch", handler)
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{DELETE, "/v1/delete", http.StatusOK},
+		{POST, "/v1/delete", http.StatusMethodNotAllowed},
+		{PATCH, "/v1/patch", http.StatusOK},
+		{OPTIONS, "/v1/options", http.StatusOK},
+		{TRACE, "/v1/trace", http.StatusOK},
+		{GET, "/v1/match", http.StatusOK},
+		{POST, "/v1/match", http.StatusOK},
+		{PUT, "/v1/match", http.StatusMethodNotAllowed},
+		{CONNECT, "/v1/any", http.StatusOK},
+		{PATCH, "/patch", http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.code {
+			t.Fatalf("%s %s: status = %d, want %d", tt.method, tt.path, w.Code, tt.code)
+		}
+	}
+}
+
+func TestEngine_HandleOPTIONS(t *testing.T) {
+	engine := New()
+	engine.GET("/users/:id", func(c *Context) {})
+	engine.PUT("/users/:id", func(c *Context) {})
+	engine.OPTIONS("/custom", func(c *Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(OPTIONS, "/users/1", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("status = %d, want 405 when HandleOPTIONS is disabled", w.Code)
+	}
+
+	engine.HandleOPTIONS = true
+	tests := []struct {
+		path  string
+		code  int
+		allow string
+	}{
+		{"/users/1", http.StatusNoContent, "GET, OPTIONS, PUT"},
+		{"/custom", http.StatusOK, ""},
+		{"/posts", http.StatusNotFound, ""},
+		{"*", http.StatusNoContent, "GET, OPTIONS, PUT"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(OPTIONS, tt.path, nil))
+		if w.Code != tt.code || w.Header().Get("Allow") != tt.allow {
+			t.Fatalf("%s: status = %d, Allow = %q", tt.path, w.Code, w.Header().Get("Allow"))
+		}
+	}
+}
+
+func TestEngine_Redirect(t *testing.T) {
+	engine := New()
+	engine.RedirectFixedPath = true
+	handler := func(c *Context) {}
+	engine.GET("/users", handler)
+	engine.GET("/Users/:id/Posts/", handler)
+	engine.POST("/users/:id", handler)
+	engine.GET("/static/*filepath", handler)
+	engine.GET("/files/:name", handler)
+
+	tests := []struct {
+		method   string
+		path     string
+		code     int
+		location string
+	}{
+		{GET, "/users/", http.StatusMovedPermanently, "/users"},
+		{GET, "/users/?page=2", http.StatusMovedPermanently, "/users?page=2"},
+		{POST, "/users/1/", http.StatusPermanentRedirect, "/users/1"},
+		{GET, "/USERS", http.StatusMovedPermanently, "/users"},
+		{GET, "//users", http.StatusMovedPermanently, "/users"},
+		{GET, "/a/../users", http.StatusMovedPermanently, "/users"},
+		{GET, "/users/Tom/posts", http.StatusMovedPermanently, "/Users/Tom/Posts/"},
+		{GET, "/STATIC/Main.css", http.StatusMovedPermanently, "/static/Main.css"},
+		{GET, "/users", http.StatusOK, ""},
+		{GET, "/posts", http.StatusNotFound, ""},
+		{GET, "/files/a%3Fb/?x=1", http.StatusMovedPermanently, "/files/a%3Fb?x=1"},
+		{GET, "/files/a%23b%20c/", http.StatusMovedPermanently, "/files/a%23b%20c"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.code || w.Header().Get("Location") != tt.location {
+			t.Fatalf("%s %s: status = %d, Location = %q", tt.method, tt.path, w.Code, w.Header().Get("Location"))
+		}
+	}
+
+	engine.RedirectTrailingSlash = false
+	engine.RedirectFixedPath = false
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(GET, "/users/", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404 when redirects are disabled", w.Code)
+	}
+}
+
+func TestEngine_Redirect_openRedirect(t *testing.T) {
+	engine := New()
+	engine.GET("/:page", func(c *Context) {})
+
+	for _, p := range []string{"/\\evil.com/", "/%5Cevil.com/", "/%2Fevil.com/"} {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(GET, p, nil))
+		if location := w.Header().Get("Location"); location != "" {
+			t.Fatalf("%s: redirected to %q", p, location)
+		}
+	}
+	if !isLocalPath("/a") || !isLocalPath("/") || isLocalPath("//a") || isLocalPath("/\\a") || isLocalPath("a") {
+		t.Fatal("isLocalPath")
+	}
+}
+
+func Test_cleanPath(t *testing.T) {
+	tests := map[string]string{
+		"":           "/",
+		"users":      "/users",
+		"//users//":  "/users/",
+		"/a/./b/../": "/a/",
+		"/../users":  "/users",
+	}
+	for p, want := range tests {
+		if got := cleanPath(p); got != want {
+			t.Fatalf("cleanPath(%q) = %q, want %q", p, got, want)
+		}
+	}
+}
+
 func Test_router_handle(t *testing.T) {
 
 }
+
+func TestEngine_AddRemoveRoute(t *testing.T) {
+	engine := New()
+	engine.GET("/static", func(c *Context) { c.Status(http.StatusOK) })
+
+	route, err := engine.AddRoute(GET, "/plugins/:id", func(c *Context) {
+		c.String(http.StatusOK, c.Param("id"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	route.Name("plugin")
+	if _, err := engine.AddRoute(GET, "/plugins/:name", nil); err == nil {
+		t.Fatal("conflicting route should be rejected")
+	}
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(GET, "/plugins/7", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "7" {
+		t.Fatalf("status = %d, body = %q", w.Code, w.Body.String())
+	}
+
+	if err := route.Remove(); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.RemoveRoute(GET, "/plugins/:id"); err == nil {
+		t.Fatal("removing a missing route should fail")
+	}
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(GET, "/plugins/7", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want 404 after removal", w.Code)
+	}
+	if _, err := engine.URL("plugin", "id", 7); err == nil {
+		t.Fatal("route name should be removed with the route")
+	}
+	if len(engine.Routes()) != 1 {
+		t.Fatalf("Routes() = %v", engine.Routes())
+	}
+	if _, err := engine.AddRoute(GET, "/plugins/:name", nil); err != nil {
+		t.Fatalf("route should be registrable after removal: %v", err)
+	}
+}
+
+func TestEngine_AddRouteConcurrent(t *testing.T) {
+	engine := New()
+	engine.GET("/static", func(c *Context) { c.Status(http.StatusOK) })
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				w := httptest.NewRecorder()
+				engine.ServeHTTP(w, httptest.NewRequest(GET, "/static", nil))
+				if w.Code != http.StatusOK {
+					t.Errorf("status = %d while routes change", w.Code)
+					return
+				}
+				engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(GET, "/plugins/1/x", nil))
+			}
+		}()
+	}
+
+	api := engine.Host("api.example.com")
+	for i := 0; i < 50; i++ {
+		pattern := fmt.Sprintf("/plugins/:id/p%d", i)
+		if _, err := engine.AddRoute(GET, pattern, func(c *Context) {}); err != nil {
+			t.Fatal(err)
+		}
+		api.GET(pattern, func(c *Context) {})
+		if i%2 == 0 {
+			if err := engine.RemoveRoute(GET, pattern); err != nil {
+				t.Fatal(err)
+			}
+		}
+		engine.Routes()
+	}
+	close(done)
+	wg.Wait()
+}
@@ -0,0 +1,245 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// encodeTOML 将结构体或映射编码为 TOML
+// 结构体字段使用 toml 标签, 未设置时使用字段名; 为 nil 的值会被忽略
+func encodeTOML(w io.Writer, data interface{}) error {
+	v := indirect(reflect.ValueOf(data))
+	if !isTOMLTable(v) {
+		return fmt.Errorf("zero: toml: top-level value must be a struct or map, got %T", data)
+	}
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer putBuffer(buf)
+	if err := writeTOMLTable(buf, nil, v); err != nil {
+		return err
+	}
+	_, err := w.Write(bytes.TrimPrefix(buf.Bytes(), []byte("\n")))
+	return err
+}
+
+// writeTOMLTable 写入表, 先写入键值对, 再写入子表与表数组
+func writeTOMLTable(b *bytes.Buffer, path []string, v reflect.Value) error {
+	var tables, arrays []encodeField
+	for _, f := range tomlFields(v) {
+		fv := indirect(f.value)
+		switch {
+		case !fv.IsValid():
+			continue
+		case isTOMLTable(fv):
+			tables = append(tables, encodeField{name: f.name, value: fv})
+		case isTOMLArrayOfTables(fv):
+			arrays = append(arrays, encodeField{name: f.name, value: fv})
+		default:
+			value, err := tomlValue(fv)
+			if err != nil {
+				return fmt.Errorf("zero: toml: %s: %v", tomlPath(append(path, f.name)), err)
+			}
+			b.WriteString(tomlKey(f.name))
+			b.WriteString(" = ")
+			b.WriteString(value)
+			b.WriteByte('\n')
+		}
+	}
+
+	for _, f := range tables {
+		sub := append(path[:len(path):len(path)], f.name)
+		b.WriteString("\n[" + tomlPath(sub) + "]\n")
+		if err := writeTOMLTable(b, sub, f.value); err != nil {
+			return err
+		}
+	}
+	for _, f := range arrays {
+		sub := append(path[:len(path):len(path)], f.name)
+		for i := 0; i < f.value.Len(); i++ {
+			b.WriteString("\n[[" + tomlPath(sub) + "]]\n")
+			if err := writeTOMLTable(b, sub, indirect(f.value.Index(i))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// tomlFields 返回表中的键值对
+func tomlFields(v reflect.Value) []encodeField {
+	if v.Kind() == reflect.Map {
+		return mapFields(v)
+	}
+	return structFields(v, "toml", false)
+}
+
+// isTOMLTable 是否编码为表
+func isTOMLTable(v reflect.Value) bool {
+	if _, ok, _ := marshalText(v); ok {
+		return false
+	}
+	return v.Kind() == reflect.Struct || v.Kind() == reflect.Map
+}
+
+// isTOMLArrayOfTables 是否编码为表数组, 即元素全部为表的非空数组
+func isTOMLArrayOfTables(v reflect.Value) bool {
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array || v.Len() == 0 {
+		return false
+	}
+	for i := 0; i < v.Len(); i++ {
+		if !isTOMLTable(indirect(v.Index(i))) {
+			return false
+		}
+	}
+	return true
+}
+
+// tomlValue 返回值的 TOML 表示, 数组中的表编码为内联表
+func tomlValue(v reflect.Value) (string, error) {
+	if !v.IsValid() {
+		return "", fmt.Errorf("nil values are not supported")
+	}
+	if v.Type() == timeType {
+		return v.Interface().(time.Time).Format(time.RFC3339Nano), nil
+	}
+	if text, ok, err := marshalText(v); ok || err != nil {
+		return tomlString(text), err
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		// 与 encoding/json 一致, []byte 编码为 base64 字符串
+		return tomlString(base64.StdEncoding.EncodeToString(v.Bytes())), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		switch {
+		case math.IsInf(f, 1):
+			return "inf", nil
+		case math.IsInf(f, -1):
+			return "-inf", nil
+		case math.IsNaN(f):
+			return "nan", nil
+		}
+		s := strconv.FormatFloat(f, 'g', -1, v.Type().Bits())
+		if !strings.ContainsAny(s, ".e") {
+			// TOML 浮点数必须包含小数点或指数
+			s += ".0"
+		}
+		return s, nil
+	case reflect.String:
+		return tomlString(v.String()), nil
+	case reflect.Slice, reflect.Array:
+		items := make([]string, v.Len())
+		for i := range items {
+			item, err := tomlValue(indirect(v.Index(i)))
+			if err != nil {
+				return "", err
+			}
+			items[i] = item
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case reflect.Struct, reflect.Map:
+		var items []string
+		for _, f := range tomlFields(v) {
+			fv := indirect(f.value)
+			if !fv.IsValid() {
+				continue
+			}
+			item, err := tomlValue(fv)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, tomlKey(f.name)+" = "+item)
+		}
+		if len(items) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(items, ", ") + " }", nil
+	}
+	return "", fmt.Errorf("unsupported type %s", v.Type())
+}
+
+// tomlPath 返回表名, 例如 a.b
+func tomlPath(path []string) string {
+	keys := make([]string, len(path))
+	for i, key := range path {
+		keys[i] = tomlKey(key)
+	}
+	return strings.Join(keys, ".")
+}
+
+// tomlKey 仅包含字母、数字、下划线与短横线的键直接输出, 否则加引号
+func tomlKey(key string) string {
+	if key == "" {
+		return `""`
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-') {
+			return tomlString(key)
+		}
+	}
+	return key
+}
+
+// tomlString 返回 TOML 基本字符串
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
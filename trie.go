@@ -17,73 +17,328 @@
 
 package zero
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
+// nodeKind 节点类型
+type nodeKind uint8
+
+const (
+	static   nodeKind = iota // 静态节点, 例如 /hello
+	param                    // 参数节点, 例如 :name
+	catchAll                 // 通配节点, 例如 *filepath
+)
+
+// node 压缩前缀树(radix tree)节点
+// 匹配优先级: 静态节点 > 参数节点 > 通配节点
 type node struct {
-	pattern  string  // 待匹配路由，例如 /p/:lang
-	part     string  // 路由中的一部分，例如 :lang
-	children []*node // 子节点，例如 [doc, tutorial, intro]
-	isWild   bool    // 是否精确匹配，part 含有 : 或 * 时为true
+	path         string        // 静态节点为压缩后的公共前缀, 参数节点为 :name, 通配节点为 *name
+	kind         nodeKind      // 节点类型
+	indices      string        // 静态子节点首字节索引, 与 children 一一对应
+	children     []*node       // 静态子节点
+	wildChildren []*node       // 参数子节点, 带约束的节点在前
+	catchChild   *node         // 通配子节点
+	pattern      string        // 待匹配路由，例如 /p/:lang, 非空时表示该节点可匹配
+	paramNames   []string      // 路由中按顺序出现的参数名
+	origin       string        // 首次创建该参数或通配节点的路由, 用于冲突提示
+	constraint   Constraint    // 参数约束, 为空时匹配任意非空值
+	handlers     []HandlerFunc // 路由处理函数
+	route        *Route        // 对应的 Engine 路由
 }
 
-// matchChild 第一个匹配成功的节点，用于插入
-func (n *node) matchChild(part string) *node {
-	for _, child := range n.children {
-		if child.part == part || child.isWild {
-			return child
+// longestCommonPrefix 最长公共前缀长度
+func longestCommonPrefix(a, b string) int {
+	max := len(a)
+	if len(b) < max {
+		max = len(b)
+	}
+	i := 0
+	for i < max && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+// wildcardEnd 参数或静态片段的结束位置
+func wildcardEnd(path string) int {
+	if end := strings.IndexByte(path, '/'); end >= 0 {
+		return end
+	}
+	return len(path)
+}
+
+// staticEnd 静态片段的结束位置,即第一个 : 或 * 出现的位置
+func staticEnd(path string) int {
+	if end := strings.IndexAny(path, ":*"); end >= 0 {
+		return end
+	}
+	return len(path)
+}
+
+// parseParamNames 按顺序解析 pattern 中的参数名
+func parseParamNames(pattern string) []string {
+	var names []string
+	for _, part := range parsePattern(pattern) {
+		if i := strings.IndexAny(part, ":*"); i >= 0 && len(part) > i+1 {
+			name, _ := splitParam(part[i:])
+			names = append(names, name)
 		}
 	}
-	return nil
+	return names
 }
 
-// matchChildren 所有匹配成功的节点，用于查找
-func (n *node) matchChildren(part string) []*node {
-	nodes := make([]*node, 0)
-	for _, child := range n.children {
-		if child.part == part || child.isWild {
-			nodes = append(nodes, child)
+// validatePattern 校验路由格式, 返回不合法的原因, 合法时返回空字符串
+// 参数必须具名, 每段只允许一个参数, 约束不能包含 /, 通配只能位于末尾
+func validatePattern(pattern string) string {
+	if pattern == "" || pattern[0] != '/' {
+		return "pattern must begin with '/'"
+	}
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		if c != ':' && c != '*' {
+			continue
+		}
+		end := i + 1 + wildcardEnd(pattern[i+1:])
+		segment := pattern[i:end]
+		name, expr := splitParam(segment)
+		if strings.IndexByte(segment, '<') >= 0 && expr == "" {
+			return fmt.Sprintf("constraint of %s must be non-empty, end with '>' and not contain '/'", segment)
+		}
+		if strings.IndexAny(name, ":*<>") >= 0 {
+			return fmt.Sprintf("only one wildcard per path segment is allowed, has %s", segment)
+		}
+		if c == ':' && name == "" {
+			return "wildcards must be named with a non-empty name"
+		}
+		if c == '*' {
+			if expr != "" {
+				return fmt.Sprintf("catch-all %s does not support constraints", segment)
+			}
+			if end != len(pattern) {
+				return fmt.Sprintf("catch-all %s is only allowed at the end of the path, the rest is shadowed", segment)
+			}
+			if pattern[i-1] != '/' {
+				return fmt.Sprintf("catch-all %s must follow a '/'", segment)
+			}
+		}
+		i = end - 1
+	}
+	return ""
+}
+
+// clone 浅拷贝节点, 子节点切片使用新的底层数组
+func (n *node) clone() *node {
+	c := *n
+	c.children = append([]*node(nil), n.children...)
+	c.wildChildren = append([]*node(nil), n.wildChildren...)
+	return &c
+}
+
+// insert 插入路由, 返回路由末尾对应的节点
+// 同一位置的参数名或通配名不一致时返回冲突错误
+// 插入采用路径复制: 沿途经过的已有节点均被拷贝, 调用方需保证 n 本身已是拷贝,
+// 因此插入过程不会修改正在被读取的旧树
+func (n *node) insert(pattern string) (*node, *RouteError) {
+	path := pattern
+	for len(path) > 0 {
+		switch path[0] {
+		case ':':
+			end := wildcardEnd(path)
+			child, err := n.insertParam(path[:end], pattern)
+			if err != nil {
+				return nil, err
+			}
+			n, path = child, path[end:]
+		case '*':
+			for _, child := range n.wildChildren {
+				if child.constraint == nil {
+					return nil, &RouteError{
+						Pattern:  pattern,
+						Conflict: child.origin,
+						Reason:   fmt.Sprintf("catch-all %s conflicts with parameter %s at the same position", path, child.path),
+					}
+				}
+			}
+			if n.catchChild == nil {
+				n.catchChild = &node{path: path, kind: catchAll, origin: pattern}
+			} else if n.catchChild.path == path {
+				n.catchChild = n.catchChild.clone()
+			} else {
+				return nil, &RouteError{
+					Pattern:  pattern,
+					Conflict: n.catchChild.origin,
+					Reason:   fmt.Sprintf("ambiguous wildcard %s, already registered as %s", path, n.catchChild.path),
+				}
+			}
+			return n.catchChild, nil
+		default:
+			end := staticEnd(path)
+			i := strings.IndexByte(n.indices, path[0])
+			if i < 0 {
+				// 当子节点不存在时,创建
+				child := &node{path: path[:end]}
+				n.indices += string(path[0])
+				n.children = append(n.children, child)
+				n, path = child, path[end:]
+				continue
+			}
+
+			child := n.children[i].clone()
+			n.children[i] = child
+			l := longestCommonPrefix(path[:end], child.path)
+			if l < len(child.path) {
+				// 拆分子节点, 公共前缀成为新的父节点
+				split := *child
+				split.path = child.path[l:]
+				*child = node{
+					path:     child.path[:l],
+					indices:  string(split.path[0]),
+					children: []*node{&split},
+				}
+			}
+			n, path = child, path[l:]
+		}
+	}
+	return n, nil
+}
+
+// insertParam 返回参数子节点, 不存在时创建
+// 约束相同而参数名不同的参数节点视为冲突, 约束不同的参数节点可以共存
+// 无约束的参数节点与通配节点位于同一位置时视为冲突, 带约束的参数节点不满足约束时回退到通配节点
+func (n *node) insertParam(wildcard string, pattern string) (*node, *RouteError) {
+	_, expr := splitParam(wildcard)
+	for i, child := range n.wildChildren {
+		if child.path == wildcard {
+			child = child.clone()
+			n.wildChildren[i] = child
+			return child, nil
+		}
+		if _, childExpr := splitParam(child.path); childExpr == expr {
+			return nil, &RouteError{
+				Pattern:  pattern,
+				Conflict: child.origin,
+				Reason:   fmt.Sprintf("ambiguous wildcard %s, already registered as %s", wildcard, child.path),
+			}
+		}
+	}
+
+	constraint, err := compileConstraint(expr)
+	if err != nil {
+		return nil, &RouteError{Pattern: pattern, Reason: fmt.Sprintf("invalid constraint %s: %v", wildcard, err)}
+	}
+	if constraint == nil && n.catchChild != nil {
+		return nil, &RouteError{
+			Pattern:  pattern,
+			Conflict: n.catchChild.origin,
+			Reason:   fmt.Sprintf("parameter %s conflicts with catch-all %s at the same position", wildcard, n.catchChild.path),
 		}
 	}
-	return nodes
+	child := &node{path: wildcard, kind: param, origin: pattern, constraint: constraint}
+
+	// 无约束的参数节点始终位于末尾, 保证带约束的节点优先匹配
+	i := len(n.wildChildren)
+	if constraint != nil && i > 0 && n.wildChildren[i-1].constraint == nil {
+		i--
+	}
+	n.wildChildren = append(n.wildChildren, nil)
+	copy(n.wildChildren[i+1:], n.wildChildren[i:])
+	n.wildChildren[i] = child
+	return child, nil
 }
 
-// insert 插入patten
-func (n *node) insert(pattern string, parts []string, depth int) {
-	if len(parts) == depth {
-		n.pattern = pattern
-		return
+// search 搜索节点, 路径参数按顺序追加到 params 中
+// 当前节点自身的 path 已被匹配, path 为剩余待匹配部分
+func (n *node) search(path string, params *Params) *node {
+	if len(path) == 0 {
+		if n.pattern != "" {
+			return n
+		}
+		// 通配节点可以匹配空路径
+		if n.catchChild != nil && n.catchChild.pattern != "" {
+			return n.catchChild.matchCatchAll(path, params)
+		}
+		return nil
+	}
+
+	// 静态节点
+	if i := strings.IndexByte(n.indices, path[0]); i >= 0 {
+		child := n.children[i]
+		if strings.HasPrefix(path, child.path) {
+			if result := child.search(path[len(child.path):], params); result != nil {
+				return result
+			}
+		}
+	}
+
+	// 参数节点, 不满足约束时继续尝试其他节点
+	if end := wildcardEnd(path); end > 0 {
+		value := path[:end]
+		for _, child := range n.wildChildren {
+			if child.constraint != nil && !child.constraint(value) {
+				continue
+			}
+			*params = append(*params, Param{Value: value})
+			if result := child.search(path[end:], params); result != nil {
+				return result
+			}
+			*params = (*params)[:len(*params)-1]
+		}
 	}
 
-	part := parts[depth]
-	// 匹配到一个子节点,用于插入
-	child := n.matchChild(part)
-	if child == nil {
-		// 当子节点不存在时,创建
-		child = &node{part: part, isWild: part[0] == ':' || part[0] == '*'}
-		n.children = append(n.children, child)
+	// 通配节点
+	if n.catchChild != nil && n.catchChild.pattern != "" {
+		return n.catchChild.matchCatchAll(path, params)
+	}
+
+	return nil
+}
+
+// matchCatchAll 通配节点匹配剩余全部路径
+func (n *node) matchCatchAll(path string, params *Params) *node {
+	if len(n.path) > 1 {
+		*params = append(*params, Param{Value: path})
 	}
-	// 进一步插入子子节点
-	child.insert(pattern, parts, depth+1)
+	return n
 }
 
-// search 搜索节点
-func (n *node) search(parts []string, depth int) *node {
-	if len(parts) == depth || strings.HasPrefix(n.part, "*") {
-		if n.pattern == "" {
-			return nil
+// searchFold 忽略大小写搜索节点, 将匹配到的规范路径追加到 buf 中返回, 未匹配时返回 nil
+// 静态部分使用已注册路由的写法, 参数部分保留请求中的原值
+func (n *node) searchFold(path string, buf []byte) []byte {
+	if len(path) == 0 {
+		if n.pattern != "" || (n.catchChild != nil && n.catchChild.pattern != "") {
+			return buf
 		}
-		return n
+		return nil
 	}
 
-	part := parts[depth]
-	children := n.matchChildren(part)
+	// 静态节点
+	for _, child := range n.children {
+		if len(path) >= len(child.path) && strings.EqualFold(path[:len(child.path)], child.path) {
+			if result := child.searchFold(path[len(child.path):], append(buf, child.path...)); result != nil {
+				return result
+			}
+		}
+	}
 
-	for _, child := range children {
-		result := child.search(parts, depth+1)
-		if result != nil {
-			return result
+	// 参数节点
+	if end := wildcardEnd(path); end > 0 {
+		value := path[:end]
+		for _, child := range n.wildChildren {
+			if child.constraint != nil && !child.constraint(value) {
+				continue
+			}
+			if result := child.searchFold(path[end:], append(buf, value...)); result != nil {
+				return result
+			}
 		}
 	}
 
+	// 通配节点
+	if n.catchChild != nil && n.catchChild.pattern != "" {
+		return append(buf, path...)
+	}
+
 	return nil
 }
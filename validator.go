@@ -0,0 +1,354 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Validator 结构体校验器, 在 Context.ShouldBind 系列方法解析请求后调用
+type Validator interface {
+	// ValidateStruct 校验 obj, obj 不是结构体或结构体指针时应直接返回 nil
+	ValidateStruct(obj interface{}) error
+}
+
+// DefaultValidator 默认校验器, 设置为 nil 时不进行校验
+// 规则通过 binding 标签声明, 多条规则以逗号分隔, 例如 `binding:"required,min=3,max=32"`
+//
+//	required  必须为非零值, 切片与映射长度不能为 0
+//	omitempty 为零值时跳过其余规则
+//	min/max   数字比较大小, 字符串比较字符数, 切片与映射比较长度
+//	len       数字必须相等, 字符串、切片与映射的长度必须相等
+//	email     必须为邮箱地址
+//	oneof     必须为以空格分隔的候选值之一, 例如 oneof=red green
+//	regex     必须匹配正则表达式, 该规则会占用标签的剩余部分, 因此必须放在最后
+//
+// 结构体字段、结构体指针以及结构体切片中的元素会被递归校验
+var DefaultValidator Validator = &defaultValidator{}
+
+// FieldError 字段校验错误
+type FieldError struct {
+	Field   string      `json:"field"`           // 字段路径, 例如 Address.City 或 Items[0].Name
+	Tag     string      `json:"tag"`             // 未通过的规则
+	Param   string      `json:"param,omitempty"` // 规则参数
+	Value   interface{} `json:"-"`               // 字段值
+	Message string      `json:"message"`         // 错误描述
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+// ValidationErrors 校验错误列表
+type ValidationErrors []*FieldError
+
+func (ve ValidationErrors) Error() string {
+	messages := make([]string, len(ve))
+	for i, e := range ve {
+		messages[i] = e.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// validate 使用默认校验器校验 obj
+func validate(obj interface{}) error {
+	if DefaultValidator == nil {
+		return nil
+	}
+	return DefaultValidator.ValidateStruct(obj)
+}
+
+type defaultValidator struct {
+	regexps sync.Map // 已编译的正则表达式
+}
+
+var emailRegexp = regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// ValidateStruct 校验结构体, 规则不合法时返回普通错误, 校验未通过时返回 ValidationErrors
+func (v *defaultValidator) ValidateStruct(obj interface{}) error {
+	value := reflect.ValueOf(obj)
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs ValidationErrors
+	if err := v.validateStruct(value, "", &errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validateStruct 校验结构体的全部导出字段, prefix 为字段路径前缀
+func (v *defaultValidator) validateStruct(value reflect.Value, prefix string, errs *ValidationErrors) error {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		path := field.Name
+		if field.Anonymous {
+			path = strings.TrimSuffix(prefix, ".")
+		} else {
+			path = prefix + path
+		}
+		if err := v.validateField(value.Field(i), path, field.Tag.Get("binding"), errs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateField 按规则校验字段, 并递归校验其中的结构体
+func (v *defaultValidator) validateField(value reflect.Value, path string, tag string, errs *ValidationErrors) error {
+	if tag == "-" {
+		return nil
+	}
+	for len(tag) > 0 {
+		var rule string
+		if strings.HasPrefix(tag, "regex=") {
+			rule, tag = tag, ""
+		} else if i := strings.IndexByte(tag, ','); i >= 0 {
+			rule, tag = tag[:i], tag[i+1:]
+		} else {
+			rule, tag = tag, ""
+		}
+		name, param := rule, ""
+		if i := strings.IndexByte(rule, '='); i >= 0 {
+			name, param = rule[:i], rule[i+1:]
+		}
+		if name == "" {
+			continue
+		}
+		if name == "omitempty" {
+			if isZeroValue(value) {
+				return nil
+			}
+			continue
+		}
+
+		ok, err := v.check(value, name, param)
+		if err != nil {
+			return fmt.Errorf("zero: invalid validation rule %q on %s: %v", rule, path, err)
+		}
+		if !ok {
+			*errs = append(*errs, newFieldError(path, name, param, value))
+			if name == "required" {
+				// 缺少的值无需再校验其他规则
+				return nil
+			}
+		}
+	}
+	return v.dive(value, path, errs)
+}
+
+// dive 递归校验结构体、结构体指针以及切片中的结构体
+func (v *defaultValidator) dive(value reflect.Value, path string, errs *ValidationErrors) error {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		if !value.CanInterface() {
+			return nil
+		}
+		prefix := path
+		if prefix != "" {
+			prefix += "."
+		}
+		return v.validateStruct(value, prefix, errs)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := v.dive(value.Index(i), fmt.Sprintf("%s[%d]", path, i), errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// check 校验单条规则
+func (v *defaultValidator) check(value reflect.Value, name, param string) (bool, error) {
+	switch name {
+	case "required":
+		return !isZeroValue(value), nil
+	case "min", "max", "len":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false, err
+		}
+		size, ok := sizeOf(value)
+		if !ok {
+			return false, fmt.Errorf("unsupported type %s", value.Type())
+		}
+		switch name {
+		case "min":
+			return size >= limit, nil
+		case "max":
+			return size <= limit, nil
+		default:
+			return size == limit, nil
+		}
+	case "email":
+		s, ok := stringOf(value)
+		if !ok {
+			return false, fmt.Errorf("unsupported type %s", value.Type())
+		}
+		return emailRegexp.MatchString(s), nil
+	case "oneof":
+		s := fmt.Sprint(indirectValue(value))
+		for _, option := range strings.Fields(param) {
+			if s == option {
+				return true, nil
+			}
+		}
+		return false, nil
+	case "regex":
+		s, ok := stringOf(value)
+		if !ok {
+			return false, fmt.Errorf("unsupported type %s", value.Type())
+		}
+		re, err := v.regexp(param)
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(s), nil
+	}
+	return false, fmt.Errorf("unknown rule %q", name)
+}
+
+// regexp 返回缓存的正则表达式
+func (v *defaultValidator) regexp(expr string) (*regexp.Regexp, error) {
+	if re, ok := v.regexps.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	v.regexps.Store(expr, re)
+	return re, nil
+}
+
+// newFieldError 创建字段错误
+func newFieldError(path, tag, param string, value reflect.Value) *FieldError {
+	var message string
+	switch tag {
+	case "required":
+		message = fmt.Sprintf("%s is required", path)
+	case "min":
+		message = fmt.Sprintf("%s must be at least %s", path, param)
+	case "max":
+		message = fmt.Sprintf("%s must be at most %s", path, param)
+	case "len":
+		message = fmt.Sprintf("%s must be exactly %s", path, param)
+	case "email":
+		message = fmt.Sprintf("%s must be a valid email address", path)
+	case "oneof":
+		message = fmt.Sprintf("%s must be one of [%s]", path, param)
+	case "regex":
+		message = fmt.Sprintf("%s must match %s", path, param)
+	default:
+		message = fmt.Sprintf("%s failed on the %s rule", path, tag)
+	}
+	var v interface{}
+	if value.IsValid() && value.CanInterface() {
+		v = value.Interface()
+	}
+	return &FieldError{Field: path, Tag: tag, Param: param, Value: v, Message: message}
+}
+
+// isZeroValue 是否为零值, 切片与映射长度为 0 时也视为零值
+func isZeroValue(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	case reflect.Invalid:
+		return true
+	}
+	return value.IsZero()
+}
+
+// indirectValue 解引用指针, 返回可比较的值
+func indirectValue(value reflect.Value) interface{} {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	if !value.CanInterface() {
+		return nil
+	}
+	return value.Interface()
+}
+
+// sizeOf 数字返回其值, 字符串返回字符数, 切片、数组与映射返回长度
+func sizeOf(value reflect.Value) (float64, bool) {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return 0, true
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	case reflect.String:
+		return float64(utf8.RuneCountInString(value.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(value.Len()), true
+	}
+	return 0, false
+}
+
+// stringOf 返回字符串字段的值
+func stringOf(value reflect.Value) (string, bool) {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return "", true
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.String {
+		return "", false
+	}
+	return value.String(), true
+}
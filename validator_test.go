@@ -0,0 +1,136 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type validateAddress struct {
+	City string `binding:"required"`
+	Zip  string `binding:"omitempty,len=6,regex=^[0-9]+$"`
+}
+
+type validateItem struct {
+	Name  string `binding:"required"`
+	Count int    `binding:"min=1,max=99"`
+}
+
+type validateUser struct {
+	Name    string           `json:"name" binding:"required,min=2,max=8"`
+	Email   string           `json:"email" binding:"required,email"`
+	Color   string           `json:"color" binding:"oneof=red green"`
+	Age     *int             `json:"age" binding:"omitempty,min=18"`
+	Address validateAddress  `json:"address"`
+	Backup  *validateAddress `json:"backup"`
+	Items   []validateItem   `json:"items" binding:"required,max=2"`
+}
+
+func TestDefaultValidator(t *testing.T) {
+	age := 20
+	valid := validateUser{
+		Name:    "chenquan",
+		Email:   "chenquan@example.com",
+		Color:   "red",
+		Age:     &age,
+		Address: validateAddress{City: "Shanghai", Zip: "200000"},
+		Items:   []validateItem{{Name: "a", Count: 1}},
+	}
+	if err := DefaultValidator.ValidateStruct(&valid); err != nil {
+		t.Fatalf("valid struct: %v", err)
+	}
+
+	age = 3
+	invalid := validateUser{
+		Name:    "c",
+		Email:   "chenquan",
+		Color:   "blue",
+		Age:     &age,
+		Address: validateAddress{Zip: "12ab56"},
+		Backup:  &validateAddress{},
+		Items:   []validateItem{{Count: 100}},
+	}
+	err := DefaultValidator.ValidateStruct(&invalid)
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("err = %v, want ValidationErrors", err)
+	}
+	want := []string{
+		"Name:min", "Email:email", "Color:oneof", "Age:min", "Address.City:required", "Address.Zip:regex",
+		"Backup.City:required", "Items[0].Name:required", "Items[0].Count:max",
+	}
+	if len(errs) != len(want) {
+		t.Fatalf("errors = %v", errs)
+	}
+	for i, e := range errs {
+		if got := e.Field + ":" + e.Tag; got != want[i] {
+			t.Fatalf("errors[%d] = %s, want %s", i, got, want[i])
+		}
+	}
+
+	if err := DefaultValidator.ValidateStruct(&validateUser{}); err == nil || !strings.Contains(err.Error(), "Items is required") {
+		t.Fatalf("required slice: %v", err)
+	}
+	if err := DefaultValidator.ValidateStruct(&struct {
+		A string `binding:"unknown"`
+	}{}); err == nil {
+		t.Fatal("unknown rule should fail")
+	} else if _, ok := err.(ValidationErrors); ok {
+		t.Fatal("unknown rule should not be reported as a field error")
+	}
+}
+
+func TestContext_ShouldBind_validate(t *testing.T) {
+	engine := New()
+	engine.POST("/users", func(c *Context) {
+		var u validateUser
+		if err := c.Bind(&u); err != nil {
+			return
+		}
+		c.String(http.StatusOK, u.Name)
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"chenquan","email":"bad","color":"red","items":[{"name":"a","count":1}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want 400", w.Code)
+	}
+	var body struct {
+		Errors []FieldError `json:"errors"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if len(body.Errors) != 2 || body.Errors[0].Field != "Email" || body.Errors[1].Field != "Address.City" {
+		t.Fatalf("body = %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"chenquan","email":"a@b.cn","color":"green","address":{"city":"x"},"items":[{"name":"a","count":1}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	engine.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Body.String() != "chenquan" {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+}
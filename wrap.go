@@ -0,0 +1,114 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WrapH 将 http.Handler 转换为 HandlerFunc
+func WrapH(h http.Handler) HandlerFunc {
+	return func(c *Context) {
+		h.ServeHTTP(c.Res, c.Req)
+	}
+}
+
+// WrapF 将 http.HandlerFunc 转换为 HandlerFunc
+func WrapF(f http.HandlerFunc) HandlerFunc {
+	return func(c *Context) {
+		f(c.Res, c.Req)
+	}
+}
+
+// WrapM 将标准库风格的中间件 func(http.Handler) http.Handler 转换为 HandlerFunc
+// 中间件调用 next 时继续执行后续处理函数, 未调用时中止处理链,
+// 中间件替换的 *http.Request 与 http.ResponseWriter 对后续处理函数可见
+func WrapM(m func(http.Handler) http.Handler) HandlerFunc {
+	return func(c *Context) {
+		req, res := c.Req, c.Res
+		called := false
+		next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			called = true
+			c.Req = r
+			if w == http.ResponseWriter(res) {
+				c.Next()
+				return
+			}
+			// 中间件替换了 ResponseWriter, 后续处理函数的写入经由中间件到达原 ResponseWriter
+			writer := &responseWriter{}
+			writer.reset(w)
+			c.Res = writer
+			c.Next()
+			// 尚有错误未输出时不写入默认状态码, 由 ErrorHandler 决定响应
+			if !c.pendingErrors() || writer.Written() {
+				writer.WriteHeaderNow()
+			}
+		})
+		m(next).ServeHTTP(res, req)
+		c.Req, c.Res = req, res
+		if !called {
+			c.Abort()
+		}
+	}
+}
+
+// Mount 将 http.Handler 挂载到 prefix 下, 所有请求方式均会转发,
+// 转发前从请求路径中去除分组前缀与 prefix, 可用于挂载另一个 *Engine
+// 转发的路径取自匹配到的通配参数, 因此分组前缀可以包含路径参数
+func (rg *RouterGroup) Mount(prefix string, h http.Handler) []*Route {
+	prefix = strings.TrimSuffix(prefix, "/")
+	var routes []*Route
+	if prefix != "" {
+		routes = rg.Any(prefix, func(c *Context) {
+			h.ServeHTTP(c.Res, forwardRequest(c.Req, ""))
+		})
+	}
+	return append(routes, rg.Any(prefix+"/*path", func(c *Context) {
+		// 通配参数始终是最后一个路径参数
+		h.ServeHTTP(c.Res, forwardRequest(c.Req, c.Params[len(c.Params)-1].Value))
+	})...)
+}
+
+// forwardRequest 返回路径替换为 "/" + rest 的请求副本
+// rest 为解码后的剩余路径, 原请求路径包含转义字符时同时保留对应的转义形式
+func forwardRequest(req *http.Request, rest string) *http.Request {
+	r := new(http.Request)
+	*r = *req
+	r.URL = new(url.URL)
+	*r.URL = *req.URL
+	r.URL.Path = "/" + rest
+	r.URL.RawPath = ""
+	if req.URL.RawPath == "" || rest == "" {
+		return r
+	}
+
+	// 从右向左查找解码后等于 rest 的转义路径后缀
+	escaped := req.URL.EscapedPath()
+	for i := len(escaped) - 1; i >= 0; i-- {
+		if escaped[i] != '/' {
+			continue
+		}
+		if tail, err := url.PathUnescape(escaped[i+1:]); err == nil && tail == rest {
+			r.URL.RawPath = escaped[i:]
+			break
+		}
+	}
+	return r
+}
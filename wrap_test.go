@@ -0,0 +1,133 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type ctxKey string
+
+func TestWrap(t *testing.T) {
+	engine := New()
+	engine.GET("/h", WrapH(http.NotFoundHandler()))
+	engine.GET("/f", WrapF(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("f"))
+	}))
+
+	withValue := WrapM(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Wrapped", "1")
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey("user"), "chenquan")))
+		})
+	})
+	deny := WrapM(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "denied", http.StatusForbidden)
+		})
+	})
+	engine.GET("/m", withValue, func(c *Context) {
+		c.String(http.StatusOK, "%v", c.Req.Context().Value(ctxKey("user")))
+	})
+	engine.GET("/deny", deny, func(c *Context) {
+		c.String(http.StatusOK, "reached")
+	})
+	// 中间件应看到 ErrorHandler 输出的状态码
+	var wrapped int
+	record := WrapM(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rec := httptest.NewRecorder()
+			next.ServeHTTP(rec, r)
+			wrapped = rec.Code
+			w.WriteHeader(rec.Code)
+			w.Write(rec.Body.Bytes())
+		})
+	})
+	engine.GET("/error", record, func(c *Context) {
+		c.Error(NewHTTPError(http.StatusTeapot, "teapot"))
+	})
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/h", http.StatusNotFound, "404 page not found\n"},
+		{"/f", http.StatusOK, "f"},
+		{"/m", http.StatusOK, "chenquan"},
+		{"/deny", http.StatusForbidden, "denied\n"},
+		{"/error", http.StatusTeapot, `{"message":"teapot"}` + "\n"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(GET, tt.path, nil))
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Fatalf("%s: status = %d, body = %q", tt.path, w.Code, w.Body.String())
+		}
+	}
+	if wrapped != http.StatusTeapot {
+		t.Fatalf("wrapped middleware saw status %d", wrapped)
+	}
+}
+
+func TestRouterGroup_Mount(t *testing.T) {
+	users := New()
+	users.GET("/", func(c *Context) {
+		c.String(http.StatusOK, "list")
+	})
+	users.POST("/:id", func(c *Context) {
+		c.String(http.StatusOK, "update %s", c.Param("id"))
+	})
+
+	engine := New()
+	engine.Group("/api").Mount("/users/", users)
+	engine.Mount("/files", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}))
+	engine.Group("/t/:tenant").Mount("/api", users)
+	engine.Mount("/raw", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.EscapedPath()))
+	}))
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		body   string
+	}{
+		{GET, "/api/users", http.StatusOK, "list"},
+		{GET, "/api/users/", http.StatusOK, "list"},
+		{POST, "/api/users/7", http.StatusOK, "update 7"},
+		{GET, "/api/users/7", http.StatusMethodNotAllowed, MethodNotAllowedError.Error()},
+		{DELETE, "/files/a/b.txt", http.StatusOK, "/a/b.txt"},
+		{GET, "/filesx", http.StatusNotFound, NotFoundError.Error()},
+		{GET, "/t/acme/api", http.StatusOK, "list"},
+		{POST, "/t/acme/api/9", http.StatusOK, "update 9"},
+		{GET, "/raw/a%2Fb/c%20d", http.StatusOK, "/a%2Fb/c%20d"},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		if w.Code != tt.code || w.Body.String() != tt.body {
+			t.Fatalf("%s %s: status = %d, body = %q", tt.method, tt.path, w.Code, w.Body.String())
+		}
+	}
+}
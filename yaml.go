@@ -0,0 +1,217 @@
+/*
+ *
+ *    Copyright 2020 Chen Quan
+ *
+ *    Licensed under the Apache License, Version 2.0 (the "License");
+ *    you may not use this file except in compliance with the License.
+ *    You may obtain a copy of the License at
+ *
+ *        http://www.apache.org/licenses/LICENSE-2.0
+ *
+ *    Unless required by applicable law or agreed to in writing, software
+ *    distributed under the License is distributed on an "AS IS" BASIS,
+ *    WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ *    See the License for the specific language governing permissions and
+ *    limitations under the License.
+ */
+
+package zero
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// yamlTimestamp 可能被解析为时间的字符串, 例如 2001-12-14 或 2001-12-14 21:59:43
+var yamlTimestamp = regexp.MustCompile(`^[0-9]{4}-[0-9]{1,2}-[0-9]{1,2}([Tt \t]|$)`)
+
+// encodeYAML 将 data 编码为块格式的 YAML
+// 结构体字段使用 yaml 标签, 未设置时使用小写的字段名, 映射按键排序输出
+func encodeYAML(w io.Writer, data interface{}) error {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer putBuffer(buf)
+	if err := writeYAML(buf, reflect.ValueOf(data), 0); err != nil {
+		return err
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writeYAML 以 indent 缩进写入一个值
+func writeYAML(b *bytes.Buffer, v reflect.Value, indent int) error {
+	v = indirect(v)
+	s, ok, err := yamlScalar(v)
+	if err != nil {
+		return err
+	}
+	if ok {
+		b.WriteString(s)
+		b.WriteByte('\n')
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return writeYAMLFields(b, structFields(v, "yaml", true), indent)
+	case reflect.Map:
+		return writeYAMLFields(b, mapFields(v), indent)
+	}
+
+	// 序列
+	for i := 0; i < v.Len(); i++ {
+		b.WriteString(strings.Repeat(" ", indent))
+		b.WriteString("- ")
+		item := indirect(v.Index(i))
+		if s, ok, err := yamlScalar(item); err != nil {
+			return err
+		} else if ok {
+			b.WriteString(s)
+			b.WriteByte('\n')
+			continue
+		}
+		// 嵌套的映射或序列以 indent+2 缩进写入, 首行紧跟在 "- " 之后
+		start := b.Len()
+		if err := writeYAML(b, item, indent+2); err != nil {
+			return err
+		}
+		data := b.Bytes()
+		copy(data[start:], data[start+indent+2:])
+		b.Truncate(len(data) - indent - 2)
+	}
+	return nil
+}
+
+// writeYAMLFields 写入映射
+func writeYAMLFields(b *bytes.Buffer, fields []encodeField, indent int) error {
+	for _, f := range fields {
+		b.WriteString(strings.Repeat(" ", indent))
+		b.WriteString(yamlString(f.name))
+		b.WriteByte(':')
+
+		v := indirect(f.value)
+		s, ok, err := yamlScalar(v)
+		if err != nil {
+			return err
+		}
+		if ok {
+			b.WriteByte(' ')
+			b.WriteString(s)
+			b.WriteByte('\n')
+			continue
+		}
+		b.WriteByte('\n')
+		if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+			// 序列与键保持相同的缩进
+			err = writeYAML(b, v, indent)
+		} else {
+			err = writeYAML(b, v, indent+2)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// yamlScalar 值为标量或空集合时返回其 YAML 表示
+func yamlScalar(v reflect.Value) (string, bool, error) {
+	if !v.IsValid() {
+		return "null", true, nil
+	}
+	if v.Type() == timeType {
+		// 时间输出为不加引号的 YAML 时间戳
+		return v.Interface().(time.Time).Format(time.RFC3339Nano), true, nil
+	}
+	if text, ok, err := marshalText(v); ok || err != nil {
+		return yamlString(text), true, err
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		// 与 encoding/json 一致, []byte 编码为 base64 字符串
+		return yamlString(base64.StdEncoding.EncodeToString(v.Bytes())), true, nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), true, nil
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		switch {
+		case math.IsInf(f, 1):
+			return ".inf", true, nil
+		case math.IsInf(f, -1):
+			return "-.inf", true, nil
+		case math.IsNaN(f):
+			return ".nan", true, nil
+		}
+		return strconv.FormatFloat(f, 'g', -1, v.Type().Bits()), true, nil
+	case reflect.String:
+		return yamlString(v.String()), true, nil
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 {
+			return "[]", true, nil
+		}
+	case reflect.Map:
+		if v.Len() == 0 {
+			return "{}", true, nil
+		}
+	case reflect.Struct:
+		if len(structFields(v, "yaml", true)) == 0 {
+			return "{}", true, nil
+		}
+	default:
+		return "", false, fmt.Errorf("zero: yaml: unsupported type %s", v.Type())
+	}
+	return "", false, nil
+}
+
+// yamlString 字符串可能被解析为其他类型或包含特殊字符时使用双引号
+func yamlString(s string) string {
+	if yamlNeedsQuote(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// yamlNeedsQuote 字符串是否需要加引号
+func yamlNeedsQuote(s string) bool {
+	if s == "" || s[0] == ' ' || s[len(s)-1] == ' ' || s[len(s)-1] == ':' {
+		return true
+	}
+	if strings.IndexByte("-?:,[]{}#&*!|>'\"%@`", s[0]) >= 0 {
+		return true
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null", "~", ".inf", "-.inf", ".nan":
+		return true
+	}
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return true
+	}
+	if _, err := strconv.ParseInt(s, 0, 64); err == nil {
+		return true
+	}
+	if yamlTimestamp.MatchString(s) {
+		return true
+	}
+	if strings.Contains(s, ": ") || strings.Contains(s, " #") {
+		return true
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
@@ -18,12 +18,18 @@
 package zero
 
 import (
+	"fmt"
 	"html/template"
+	"log"
 	"net/http"
 	"path"
-	"strings"
+	"sync"
+	"sync/atomic"
 )
 
+// defaultMultipartMemory 默认的 multipart/form-data 内存上限, 32 MB
+const defaultMultipartMemory int64 = 32 << 20
+
 // HandlerFunc defines the request handler used by gee
 type HandlerFunc func(ctx *Context)
 
@@ -31,29 +37,84 @@ type HandlerFunc func(ctx *Context)
 type Engine struct {
 	*RouterGroup
 	router        *router
-	groups        []*RouterGroup     // 存储所有group
 	htmlTemplates *template.Template // for html render
 	funcMap       template.FuncMap   // for html render
+	noRoute       []HandlerFunc      // 未匹配到路由时的处理函数
+	noMethod      []HandlerFunc      // 请求方式不被允许时的处理函数
+	hosts         atomic.Value       // []*hostRouter, 按域名匹配的路由, 精确域名在前
+	mu            sync.RWMutex       // 保护 routes、namedRoutes、err 以及 hosts 的写入
+	routes        []*Route           // 已注册的路由
+	namedRoutes   map[string]*Route  // 命名路由
+	pool          sync.Pool          // 复用 Context
+
+	// StrictRouting 为 true 时, 注册格式错误、重复或冲突的路由会直接 panic,
+	// 否则记录日志并忽略该路由, 由 Run 返回首个错误
+	StrictRouting bool
+
+	// HandleOPTIONS 为 true 时, 未注册 OPTIONS 路由的路径会根据已注册的请求方式
+	// 自动响应 OPTIONS 请求, 并设置 Allow 响应头
+	HandleOPTIONS bool
+
+	// RedirectTrailingSlash 为 true 时, 若去除或添加末尾的 / 后能匹配到路由,
+	// 则重定向到该路径, GET 请求使用 301, 其余请求使用 308
+	RedirectTrailingSlash bool
+
+	// RedirectFixedPath 为 true 时, 未匹配到路由会清理路径中多余的 /、. 与 ..
+	// 并忽略大小写查找, 找到后重定向到已注册的规范路径
+	RedirectFixedPath bool
+
+	// MaxMultipartMemory 解析 multipart/form-data 时存放在内存中的最大字节数, 超出部分写入临时文件
+	MaxMultipartMemory int64
+
+	// ErrorHandler 处理链执行完毕或中止后, 若通过 Context.Error 记录了新的错误则调用该函数输出错误,
+	// 在外层中间件恢复执行前调用, 因此中间件可获取最终的状态码; 默认为 DefaultErrorHandler, 为空时不处理
+	ErrorHandler HandlerFunc
+
+	// ProblemDetails 为 true 时, 默认的 404、405、406 响应, Context.Fail、校验失败
+	// 以及 DefaultErrorHandler 输出 RFC 7807 格式的 application/problem+json 响应
+	ProblemDetails bool
+
+	err error // 首个路由注册错误
 }
 
 func (e *Engine) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
-	var middlewares []HandlerFunc
-	// 获取分组中所有中间件
-	for _, group := range e.groups {
-		if strings.HasPrefix(request.URL.Path, group.prefix) {
-			middlewares = append(middlewares, group.middlewares...)
+	c := e.pool.Get().(*Context)
+	c.reset(writer, request)
+	// 优先按域名匹配路由, 域名参数写入路径参数, 域名路由中没有该路径时使用默认路由
+	handled := false
+	if hosts, _ := e.hosts.Load().([]*hostRouter); len(hosts) > 0 {
+		hostname := stripHostPort(request.Host)
+		for _, hr := range hosts {
+			if hr.match(hostname, &c.Params) {
+				handled = hr.router.handle(c, false)
+				break
+			}
 		}
 	}
-	c := newContext(writer, request)
-	c.handlers = middlewares
-	c.engine = e
-	e.router.handle(c)
+	if !handled {
+		c.Params = c.Params[:0]
+		e.router.handle(c, true)
+	}
+	c.handleErrors()
+	// 仅设置了状态码而未写入响应体时, 在此写入响应头
+	c.Res.WriteHeaderNow()
+	e.pool.Put(c)
 }
 
 func New() *Engine {
-	engine := &Engine{router: newRouter()}
+	engine := &Engine{
+		router:                newRouter(),
+		noRoute:               []HandlerFunc{notFound},
+		noMethod:              []HandlerFunc{methodNotAllowed},
+		namedRoutes:           make(map[string]*Route),
+		RedirectTrailingSlash: true,
+		MaxMultipartMemory:    defaultMultipartMemory,
+		ErrorHandler:          DefaultErrorHandler,
+	}
 	engine.RouterGroup = &RouterGroup{engine: engine}
-	engine.groups = []*RouterGroup{engine.RouterGroup}
+	engine.pool.New = func() interface{} {
+		return &Context{engine: engine, Params: make(Params, 0, engine.router.load().maxParams)}
+	}
 	return engine
 }
 func Default() *Engine {
@@ -62,40 +123,140 @@ func Default() *Engine {
 	return engine
 }
 
+// NoRoute 设置未匹配到路由时的处理函数, 与全局中间件一同执行
+func (e *Engine) NoRoute(handlers ...HandlerFunc) {
+	e.noRoute = handlers
+}
+
+// notFound 默认的 404 处理函数
+func notFound(c *Context) {
+	if c.problemDetails() {
+		c.Problem(NewProblem(http.StatusNotFound, ""))
+		return
+	}
+	c.String(http.StatusNotFound, NotFoundError.Error())
+}
+
+// NoMethod 设置请求方式不被允许时的处理函数, 与全局中间件一同执行
+// 执行前已设置 Allow 响应头
+func (e *Engine) NoMethod(handlers ...HandlerFunc) {
+	e.noMethod = handlers
+}
+
+// methodNotAllowed 默认的 405 处理函数
+func methodNotAllowed(c *Context) {
+	if c.problemDetails() {
+		c.Problem(NewProblem(http.StatusMethodNotAllowed, ""))
+		return
+	}
+	c.String(http.StatusMethodNotAllowed, MethodNotAllowedError.Error())
+}
+
 func (e *Engine) SetFuncMap(funcMap template.FuncMap) {
 	e.funcMap = funcMap
 }
 
+// LoadHTMLGlob 加载模板, 模板中可使用 url 函数根据路由名称生成路径
 func (e *Engine) LoadHTMLGlob(pattern string) {
-	e.htmlTemplates = template.Must(template.New("").Funcs(e.funcMap).ParseGlob(pattern))
+	funcMap := template.FuncMap{"url": e.URL}
+	for name, fn := range e.funcMap {
+		funcMap[name] = fn
+	}
+	e.htmlTemplates = template.Must(template.New("").Funcs(funcMap).ParseGlob(pattern))
 }
-func (e *Engine) addRoute(method string, pattern string, handlers ...HandlerFunc) {
-	e.router.addRoute(method, pattern, handlers...)
+
+// addRoute 添加路由, 注册失败时记录错误, 严格模式下直接 panic
+func (e *Engine) addRoute(host string, method string, pattern string, middlewares []HandlerFunc, handlers []HandlerFunc) *Route {
+	route, err := e.register(host, method, pattern, middlewares, handlers)
+	if err != nil {
+		e.routeError(err)
+	}
+	return route
 }
-func (e *Engine) GET(pattern string, handlers ...HandlerFunc) {
-	e.addRoute(GET, pattern, handlers...)
+
+// register 注册路由, host 为空时添加到默认路由
+// 分组中间件与处理函数在注册时合并, 请求时无需再查找分组
+func (e *Engine) register(host string, method string, pattern string, middlewares []HandlerFunc, handlers []HandlerFunc) (*Route, error) {
+	route := &Route{Host: host, Method: method, Pattern: pattern, middlewares: middlewares, handlers: handlers, engine: e}
+	entry := routeEntry{method: method, pattern: pattern, handlers: combineHandlers(middlewares, handlers), route: route}
+	if err := e.routerOf(host).add(entry); err != nil {
+		route.failed = true
+		return route, err
+	}
+	e.mu.Lock()
+	e.routes = append(e.routes, route)
+	e.mu.Unlock()
+	return route, nil
 }
-func (e *Engine) POST(pattern string, handlers ...HandlerFunc) {
-	e.addRoute(POST, pattern, handlers...)
+
+// routerOf 返回域名对应的路由, host 为空时返回默认路由
+func (e *Engine) routerOf(host string) *router {
+	if host == "" {
+		return e.router
+	}
+	return e.hostRouter(host).router
 }
-func (e *Engine) PUT(pattern string, handlers ...HandlerFunc) {
-	e.addRoute(PUT, pattern, handlers...)
+
+// RemoveRoute 删除默认域名下的路由, 可在处理请求的同时调用
+func (e *Engine) RemoveRoute(method string, pattern string) error {
+	return e.removeRoute("", method, pattern)
 }
-func (e *Engine) DELETE(pattern string, handlers ...HandlerFunc) {
-	e.addRoute(DELETE, pattern, handlers...)
+
+// removeRoute 删除路由, 同时删除路由名称
+func (e *Engine) removeRoute(host string, method string, pattern string) error {
+	if !e.routerOf(host).removeRoute(method, pattern) {
+		return fmt.Errorf("zero: route %s %s does not exist", method, pattern)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, route := range e.routes {
+		if route.Host == host && route.Method == method && route.Pattern == pattern {
+			e.routes = append(e.routes[:i:i], e.routes[i+1:]...)
+			break
+		}
+	}
+	for name, route := range e.namedRoutes {
+		if route.Host == host && route.Method == method && route.Pattern == pattern {
+			delete(e.namedRoutes, name)
+		}
+	}
+	return nil
 }
-func (e *Engine) HEAD(pattern string, handlers ...HandlerFunc) {
-	e.addRoute(HEAD, pattern, handlers...)
+
+// routeError 处理路由注册错误, 严格模式下直接 panic
+func (e *Engine) routeError(err error) {
+	if e.StrictRouting {
+		panic(err)
+	}
+	log.Printf("[ZERO] ROUTE ERROR %v", err)
+	e.mu.Lock()
+	if e.err == nil {
+		e.err = err
+	}
+	e.mu.Unlock()
+}
+
+// Err 返回首个路由注册错误, 没有错误时返回 nil
+// 不通过 Run 启动服务时(例如 http.ListenAndServe(addr, engine)), 应在启动前检查该错误
+func (e *Engine) Err() error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.err
 }
 
-// Run 启动http服务器
-func (e *Engine) Run(addr string) (err error) {
+// Run 启动http服务器, 存在路由注册错误时直接返回该错误
+func (e *Engine) Run(addr string) error {
+	if err := e.Err(); err != nil {
+		return err
+	}
 	return http.ListenAndServe(addr, e)
 }
 
 // 分组路由
 type RouterGroup struct {
 	prefix      string
+	host        string        // 域名, 为空时使用默认路由
 	middlewares []HandlerFunc // 中间件
 	parent      *RouterGroup  // 支持嵌套
 	engine      *Engine       // 所有组共享一个Engine实例
@@ -105,16 +266,32 @@ func (rg *RouterGroup) Group(prefix string) *RouterGroup {
 
 	newGroup := &RouterGroup{
 		prefix: rg.prefix + prefix,
+		host:   rg.host,
 		parent: rg,
 		engine: rg.engine,
 	}
-	rg.engine.groups = append(rg.engine.groups, newGroup)
 	return newGroup
 }
 
+// Use 添加中间件, 仅作用于之后注册到该分组及其子分组的路由
 func (rg *RouterGroup) Use(middlewares ...HandlerFunc) {
 	rg.middlewares = append(rg.middlewares, middlewares...)
 }
+
+// chain 返回从根分组到当前分组的全部中间件
+func (rg *RouterGroup) chain() []HandlerFunc {
+	if rg.parent == nil {
+		return combineHandlers(rg.middlewares, nil)
+	}
+	return combineHandlers(rg.parent.chain(), rg.middlewares)
+}
+
+// combineHandlers 合并处理函数, 返回新的切片, 不会修改参数
+func combineHandlers(a []HandlerFunc, b []HandlerFunc) []HandlerFunc {
+	merged := make([]HandlerFunc, 0, len(a)+len(b))
+	merged = append(merged, a...)
+	return append(merged, b...)
+}
 func (rg *RouterGroup) createStaticHandler(relativePath string, fs http.FileSystem) HandlerFunc {
 	absolutePath := path.Join(rg.prefix, relativePath)
 	fileServer := http.StripPrefix(absolutePath, http.FileServer(fs))
@@ -138,34 +315,68 @@ func (rg *RouterGroup) Static(relativePath string, root string) {
 	rg.GET(urlPattern, handler)
 }
 
+// AddRoute 添加路由并返回注册错误, 可在处理请求的同时调用
+func (rg *RouterGroup) AddRoute(method string, pattern string, handlers ...HandlerFunc) (*Route, error) {
+	return rg.engine.register(rg.host, method, rg.prefix+pattern, rg.chain(), handlers)
+}
+
 // addRoute 添加路由
-func (rg *RouterGroup) addRoute(method string, prefix string, handlers ...HandlerFunc) {
+func (rg *RouterGroup) addRoute(method string, prefix string, handlers ...HandlerFunc) *Route {
 	// 真实路径 = 分组路径+当前路径
 	pattern := rg.prefix + prefix
-	rg.engine.addRoute(method, pattern, handlers...)
+	return rg.engine.addRoute(rg.host, method, pattern, rg.chain(), handlers)
 }
 
 // GET 添加GET路由
-func (rg *RouterGroup) GET(pattern string, handlers ...HandlerFunc) {
-	rg.addRoute(GET, pattern, handlers...)
+func (rg *RouterGroup) GET(pattern string, handlers ...HandlerFunc) *Route {
+	return rg.addRoute(GET, pattern, handlers...)
 }
 
 // POST 添加POST路由
-func (rg *RouterGroup) POST(pattern string, handlers ...HandlerFunc) {
-	rg.addRoute(POST, pattern, handlers...)
+func (rg *RouterGroup) POST(pattern string, handlers ...HandlerFunc) *Route {
+	return rg.addRoute(POST, pattern, handlers...)
 }
 
 // PUT 添加PUT路由
-func (rg *RouterGroup) PUT(pattern string, handlers ...HandlerFunc) {
-	rg.addRoute(PUT, pattern, handlers...)
+func (rg *RouterGroup) PUT(pattern string, handlers ...HandlerFunc) *Route {
+	return rg.addRoute(PUT, pattern, handlers...)
 }
 
 // DELETE 添加DELETE路由
-func (rg *RouterGroup) DELETE(pattern string, handlers ...HandlerFunc) {
-	rg.addRoute(POST, pattern, handlers...)
+func (rg *RouterGroup) DELETE(pattern string, handlers ...HandlerFunc) *Route {
+	return rg.addRoute(DELETE, pattern, handlers...)
 }
 
 // HEAD 添加HEAD路由
-func (rg *RouterGroup) HEAD(pattern string, handlers ...HandlerFunc) {
-	rg.addRoute(HEAD, pattern, handlers...)
+func (rg *RouterGroup) HEAD(pattern string, handlers ...HandlerFunc) *Route {
+	return rg.addRoute(HEAD, pattern, handlers...)
+}
+
+// PATCH 添加PATCH路由
+func (rg *RouterGroup) PATCH(pattern string, handlers ...HandlerFunc) *Route {
+	return rg.addRoute(PATCH, pattern, handlers...)
+}
+
+// OPTIONS 添加OPTIONS路由
+func (rg *RouterGroup) OPTIONS(pattern string, handlers ...HandlerFunc) *Route {
+	return rg.addRoute(OPTIONS, pattern, handlers...)
+}
+
+// Any 为所有请求方式添加路由
+func (rg *RouterGroup) Any(pattern string, handlers ...HandlerFunc) []*Route {
+	return rg.Match(anyMethods, pattern, handlers...)
+}
+
+// Match 为指定的多个请求方式添加路由
+func (rg *RouterGroup) Match(methods []string, pattern string, handlers ...HandlerFunc) []*Route {
+	routes := make([]*Route, 0, len(methods))
+	for _, method := range methods {
+		routes = append(routes, rg.addRoute(method, pattern, handlers...))
+	}
+	return routes
+}
+
+// Handle 为指定请求方式添加路由
+func (rg *RouterGroup) Handle(method string, pattern string, handlers ...HandlerFunc) *Route {
+	return rg.addRoute(method, pattern, handlers...)
 }
@@ -19,6 +19,8 @@ package zero
 
 import (
 	"fmt"
+	"net/http/httptest"
+	"reflect"
 	"testing"
 )
 
@@ -56,3 +58,45 @@ func TestNew(t *testing.T) {
 	engine.Run(":8080")
 
 }
+
+func TestRouterGroup_middlewares(t *testing.T) {
+	var calls []string
+	middleware := func(name string) HandlerFunc {
+		return func(c *Context) {
+			calls = append(calls, name)
+			c.Next()
+		}
+	}
+	handler := func(c *Context) {
+		calls = append(calls, "handler")
+	}
+
+	engine := New()
+	engine.Use(middleware("global"))
+	test := engine.Group("/test")
+	test.Use(middleware("test"))
+	inner := test.Group("/inner")
+	inner.Use(middleware("inner"))
+
+	engine.GET("/testing/x", handler)
+	test.GET("/x", handler)
+	inner.GET("/x", handler)
+	test.Use(middleware("late"))
+
+	tests := []struct {
+		path  string
+		calls []string
+	}{
+		{"/testing/x", []string{"global", "handler"}},
+		{"/test/x", []string{"global", "test", "handler"}},
+		{"/test/inner/x", []string{"global", "test", "inner", "handler"}},
+		{"/test/missing", []string{"global"}},
+	}
+	for _, tt := range tests {
+		calls = nil
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(GET, tt.path, nil))
+		if !reflect.DeepEqual(calls, tt.calls) {
+			t.Fatalf("%s: calls = %v, want %v", tt.path, calls, tt.calls)
+		}
+	}
+}